@@ -140,3 +140,13 @@ func TestSub(t *testing.T) {
 		}
 	}
 }
+
+func TestCallers(t *testing.T) {
+	stack := Callers(0, 2)
+	if len(stack) != 2 {
+		t.Fatalf("len(stack) = %v want 2", len(stack))
+	}
+	if !strings.Contains(stack[0].String(), "TestCallers") {
+		t.Fatalf("first stack frame should contain \"TestCallers\": %v", stack[0].String())
+	}
+}
@@ -28,23 +28,37 @@ func Stack(err error) []StackFrame {
 	return nil
 }
 
+// Callers returns the stack trace of the calling goroutine, which holds at most
+// size frames. The argument skip is the number of frames to skip, with 0
+// identifying the caller of Callers.
+func Callers(skip int, size int) []StackFrame {
+	return getStack(skip+2, size)
+}
+
 // getStack is a formatting wrapper around runtime.Callers. It returns a stack
 // trace in the form of a StackFrame slice.
 func getStack(skip int, size int) []StackFrame {
 	var (
-		pc    = make([]uintptr, size)
-		calls = runtime.Callers(skip+1, pc)
-		trace []StackFrame
+		pc     = make([]uintptr, size)
+		calls  = runtime.Callers(skip+1, pc)
+		frames = runtime.CallersFrames(pc[:calls])
+		trace  []StackFrame
 	)
 
-	for i := 0; i < calls; i++ {
-		f := runtime.FuncForPC(pc[i])
-		file, line := f.FileLine(pc[i] - 1)
+	if calls == 0 {
+		return nil
+	}
+
+	for {
+		frame, more := frames.Next()
 		trace = append(trace, StackFrame{
-			Func: f.Name(),
-			File: file,
-			Line: line,
+			Func: frame.Function,
+			File: frame.File,
+			Line: frame.Line,
 		})
+		if !more || len(trace) == size {
+			break
+		}
 	}
 
 	return trace
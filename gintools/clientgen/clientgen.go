@@ -0,0 +1,365 @@
+// Package clientgen generate the typed clients of the API from the OpenAPI document of the openapi package.
+// The document is the snapshot of the route registry of the handler, its extensions describe the pagination
+// and the error codes of the routes, so that the clients are able to unwrap the response envelope, return the
+// typed errors and iterate the pages.
+package clientgen
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/bytom/community/gintools/openapi"
+)
+
+const (
+	componentPrefix = "#/components/schemas/"
+	// typePrefix is the prefix of the references to the types named from the inline objects
+	typePrefix = "#/clientgen/types/"
+)
+
+// envelopeComponents are the component schemas of the response envelope, which the clients define themselves
+var envelopeComponents = map[string]bool{
+	"ErrorResponse":    true,
+	"FieldError":       true,
+	"PaginationResp":   true,
+	"CursorPagination": true,
+	"links":            true,
+}
+
+// reservedNames are the names defined by the runtime of the clients
+var reservedNames = []string{"Client", "Error", "FieldError", "Pagination", "CursorPagination", "Links", "Page", "Iterator", "ApiError", "PageParams", "ClientOptions"}
+
+// methodOrder is the order of the operations of the same path
+var methodOrder = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+
+// Load decode the OpenAPI document, such as the one served by openapi.Serve
+func Load(r io.Reader) (*openapi.Document, error) {
+	doc := &openapi.Document{}
+	if err := json.NewDecoder(r).Decode(doc); err != nil {
+		return nil, errors.Wrap(err, "decode openapi document")
+	}
+	return doc, nil
+}
+
+// model is the language independent description of the client
+type model struct {
+	types      []*typeDef
+	operations []*operation
+	errors     []*errorDef
+
+	doc       *openapi.Document
+	typeNames map[string]string // the names of the types of the component schemas
+	used      map[string]bool
+}
+
+// typeDef is an object type
+type typeDef struct {
+	name   string
+	fields []*fieldDef
+}
+
+// fieldDef is a field of an object type, or a param or body field of a request
+type fieldDef struct {
+	name     string
+	jsonName string
+	in       string
+	required bool
+	schema   *openapi.Schema
+}
+
+type operation struct {
+	name       string
+	method     string
+	path       string
+	summary    string
+	request    *typeDef
+	hasBody    bool
+	data       *openapi.Schema
+	pagination *openapi.Pagination
+}
+
+type errorDef struct {
+	name    string
+	code    int
+	status  int
+	message string
+}
+
+func newModel(doc *openapi.Document) (*model, error) {
+	m := &model{doc: doc, typeNames: map[string]string{}, used: map[string]bool{}}
+	for _, name := range reservedNames {
+		m.used[name] = true
+	}
+
+	components := sortedKeys(doc.Components.Schemas)
+	for _, key := range components {
+		if !envelopeComponents[key] {
+			m.typeNames[key] = m.uniqueName(pascalCase(key))
+		}
+	}
+	for _, key := range components {
+		if !envelopeComponents[key] {
+			m.addType(m.typeNames[key], doc.Components.Schemas[key])
+		}
+	}
+
+	errorCodes := map[int]*errorDef{}
+	for _, path := range sortedKeys(doc.Paths) {
+		item := *doc.Paths[path]
+		for _, method := range methodOrder {
+			// the streaming operations are not supported by the clients
+			op, ok := item[strings.ToLower(method)]
+			if !ok || op.Stream {
+				continue
+			}
+			if op.OperationID == "" {
+				return nil, errors.New("operationId of " + method + " " + path + " is missing")
+			}
+
+			m.operations = append(m.operations, m.newOperation(method, path, op))
+			for _, code := range op.ErrorCodes {
+				if _, ok := errorCodes[code.Code]; !ok {
+					errorCodes[code.Code] = &errorDef{code: code.Code, status: code.HTTPStatus, message: code.Message}
+				}
+			}
+		}
+	}
+
+	for _, def := range errorCodes {
+		m.errors = append(m.errors, def)
+	}
+	sort.Slice(m.errors, func(i, j int) bool { return m.errors[i].code < m.errors[j].code })
+	for _, def := range m.errors {
+		def.name = strings.TrimSuffix(pascalCase(def.message), "Error")
+		if def.name == "" || !unicode.IsLetter(rune(def.name[0])) {
+			def.name = "Code" + strconv.Itoa(def.code)
+		}
+		if m.used["Err"+def.name] {
+			def.name += strconv.Itoa(def.code)
+		}
+		m.used["Err"+def.name] = true
+	}
+	return m, nil
+}
+
+func (m *model) newOperation(method, path string, o *openapi.Operation) *operation {
+	op := &operation{name: pascalCase(o.OperationID), method: method, path: path, summary: o.Summary, pagination: o.Pagination}
+	request := &typeDef{name: m.uniqueName(op.name + "Request")}
+	names := map[string]bool{}
+
+	if o.RequestBody != nil {
+		if media, ok := o.RequestBody.Content["application/json"]; ok {
+			body := media.Schema
+			if body.Ref != "" {
+				body = m.doc.Components.Schemas[strings.TrimPrefix(body.Ref, componentPrefix)]
+			}
+			for _, field := range m.fields(request.name, body) {
+				names[field.name] = true
+				request.fields = append(request.fields, field)
+			}
+			op.hasBody = len(request.fields) > 0
+		}
+	}
+
+	for _, param := range o.Parameters {
+		if op.pagination != nil && param.In == "query" && (param.Name == op.pagination.StartParam || param.Name == op.pagination.LimitParam) {
+			continue
+		}
+
+		field := &fieldDef{
+			name:     pascalCase(param.Name),
+			jsonName: param.Name,
+			in:       param.In,
+			required: param.Required,
+			schema:   m.resolve(param.Schema, request.name+pascalCase(param.Name)),
+		}
+		for names[field.name] {
+			field.name += "Param"
+		}
+		names[field.name] = true
+		request.fields = append(request.fields, field)
+	}
+	if len(request.fields) > 0 {
+		op.request = request
+	} else {
+		delete(m.used, request.name)
+	}
+
+	if success, ok := o.Responses[strconv.Itoa(http.StatusOK)]; ok {
+		if media, ok := success.Content["application/json"]; ok && media.Schema != nil {
+			if data := media.Schema.Properties["data"]; data != nil {
+				op.data = m.resolve(data, op.name+"Data")
+				if op.pagination != nil && op.data.Items != nil {
+					op.data = op.data.Items
+				}
+			}
+		}
+	}
+	return op
+}
+
+// addType add the object type of the schema
+func (m *model) addType(name string, schema *openapi.Schema) {
+	m.types = append(m.types, &typeDef{name: name, fields: m.fields(name, schema)})
+}
+
+// fields return the fields of the object schema ordered by the json name
+func (m *model) fields(typeName string, schema *openapi.Schema) []*fieldDef {
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+
+	var fields []*fieldDef
+	names := map[string]bool{}
+	for _, jsonName := range sortedKeys(schema.Properties) {
+		field := &fieldDef{
+			name:     pascalCase(jsonName),
+			jsonName: jsonName,
+			required: required[jsonName],
+			schema:   m.resolve(schema.Properties[jsonName], typeName+pascalCase(jsonName)),
+		}
+		if field.name == "" {
+			field.name = "Field"
+		}
+		for i := 2; names[field.name]; i++ {
+			field.name = pascalCase(jsonName) + strconv.Itoa(i)
+		}
+		names[field.name] = true
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// resolve return the schema whose inline objects are replaced by the references of the named types
+func (m *model) resolve(schema *openapi.Schema, hint string) *openapi.Schema {
+	switch {
+	case schema == nil || schema.Ref != "":
+		return schema
+
+	case schema.Type == "object" && len(schema.Properties) > 0:
+		name := m.uniqueName(hint)
+		m.addType(name, schema)
+		return &openapi.Schema{Ref: typePrefix + name}
+
+	case schema.Type == "array" && schema.Items != nil:
+		resolved := *schema
+		resolved.Items = m.resolve(schema.Items, hint+"Item")
+		return &resolved
+
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		resolved := *schema
+		resolved.AdditionalProperties = m.resolve(schema.AdditionalProperties, hint+"Value")
+		return &resolved
+	}
+	return schema
+}
+
+// typeName return the name of the type referred by the schema
+func (m *model) typeName(ref string) string {
+	if strings.HasPrefix(ref, typePrefix) {
+		return strings.TrimPrefix(ref, typePrefix)
+	}
+
+	key := strings.TrimPrefix(ref, componentPrefix)
+	if name, ok := m.typeNames[key]; ok {
+		return name
+	}
+	return pascalCase(key)
+}
+
+func (m *model) uniqueName(name string) string {
+	unique := name
+	for i := 2; m.used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	m.used[unique] = true
+	return unique
+}
+
+// pathSegments split the OpenAPI path into the literal parts and the params, the params are wrapped by braces
+func pathSegments(path string) []string {
+	var segments []string
+	for path != "" {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			return append(segments, path)
+		}
+		if start > 0 {
+			segments = append(segments, path[:start])
+		}
+		segments = append(segments, path[start:end+1])
+		path = path[end+1:]
+	}
+	return segments
+}
+
+// commonInitialisms are written in upper case in the Go names
+var commonInitialisms = map[string]bool{"API": true, "HTTP": true, "ID": true, "IP": true, "JSON": true, "URL": true, "UUID": true}
+
+// pascalCase convert the name to the exported Go name, such as dry_run to DryRun and X-Token to XToken
+func pascalCase(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		if upper := strings.ToUpper(word); commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// camelCase convert the name to the lower camel case, such as X-Token to xToken
+func camelCase(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		if i == 0 {
+			words[i] = strings.ToLower(word)
+		} else {
+			words[i] = strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return strings.Join(words, "")
+}
+
+// splitWords split the name by the characters other than letters and digits and by the lower to upper case changes
+func splitWords(name string) []string {
+	var (
+		words []string
+		word  []rune
+	)
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1])) {
+			words, word = append(words, string(word)), nil
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,77 @@
+package clientgen
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bytom/community/gintools/clientgen/internal/testapi"
+	"github.com/bytom/community/gintools/openapi"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+var update = flag.Bool("update", false, "update the generated test clients")
+
+func testDocument() *openapi.Document {
+	_, h := testapi.New(nil)
+	return openapi.Generate(openapi.Info{Title: "orders", Version: "1.0"}, h.Routes().Routes())
+}
+
+// checkGolden compare the generated source with the golden file, and rewrite the file if -update is set
+func checkGolden(t *testing.T, path string, src []byte) {
+	if *update {
+		if err := os.WriteFile(path, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, want) {
+		t.Errorf("generated source differs from %s, run go test -update to regenerate it", path)
+	}
+}
+
+func TestGenerateGo(t *testing.T) {
+	src, err := GenerateGo(testDocument(), "testclient")
+	if err != nil {
+		t.Fatalf("GenerateGo() error = %v", err)
+	}
+	checkGolden(t, filepath.Join("internal", "testclient", "client.go"), src)
+}
+
+func TestGenerateTypeScript(t *testing.T) {
+	src, err := GenerateTypeScript(testDocument())
+	if err != nil {
+		t.Fatalf("GenerateTypeScript() error = %v", err)
+	}
+	checkGolden(t, filepath.Join("testdata", "client.ts"), src)
+}
+
+func TestNames(t *testing.T) {
+	cases := map[string]string{
+		"dry_run":            "DryRun",
+		"X-Token":            "XToken",
+		"getV1OrdersById":    "GetV1OrdersByID",
+		"order not found":    "OrderNotFound",
+		"HTTPServer":         "HTTPServer",
+		"JSON_handler_Empty": "JSONHandlerEmpty",
+	}
+	for name, want := range cases {
+		if got := pascalCase(name); got != want {
+			t.Errorf("pascalCase(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := camelCase("X-Token"); got != "xToken" {
+		t.Errorf("camelCase(X-Token) = %q, want xToken", got)
+	}
+}
@@ -0,0 +1,506 @@
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/bytom/community/gintools/openapi"
+)
+
+// GenerateGo return the source of the Go client of the document in the package
+func GenerateGo(doc *openapi.Document, pkg string) ([]byte, error) {
+	m, err := newModel(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, goRuntime, doc.Info.Title, pkg)
+
+	if len(m.errors) > 0 {
+		buf.WriteString("\n// the errors of the API, the errors returned by the client match them by errors.Is\nvar (\n")
+		for _, def := range m.errors {
+			// the status is left unset if the error has no specific status
+			status := ""
+			if def.status != 0 {
+				status = fmt.Sprintf("Status: %d, ", def.status)
+			}
+			fmt.Fprintf(buf, "\tErr%s = &Error{%sCode: %d, Msg: %q}\n", def.name, status, def.code, def.message)
+		}
+		buf.WriteString(")\n")
+	}
+
+	for _, def := range m.types {
+		m.writeGoType(buf, def, def.name+" is a schema of the API")
+	}
+
+	for _, op := range m.operations {
+		if op.request != nil {
+			m.writeGoType(buf, op.request, fmt.Sprintf("%s is the request of %s", op.request.name, op.name))
+		}
+		m.writeGoOperation(buf, op)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, errors.Wrap(err, "format go client")
+	}
+	return src, nil
+}
+
+func (m *model) writeGoType(buf *bytes.Buffer, def *typeDef, doc string) {
+	fmt.Fprintf(buf, "\n// %s\ntype %s struct {\n", doc, def.name)
+	for _, field := range def.fields {
+		typ := m.goType(field.schema)
+		if field.in != "" {
+			if !field.required && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") {
+				typ = "*" + typ
+			}
+			fmt.Fprintf(buf, "\t%s %s `json:\"-\"` // %s %s\n", field.name, typ, field.in, field.jsonName)
+			continue
+		}
+
+		tag := field.jsonName
+		if !field.required {
+			tag += ",omitempty"
+			if field.schema.Ref != "" {
+				typ = "*" + typ
+			}
+		}
+		fmt.Fprintf(buf, "\t%s %s `json:%q`\n", field.name, typ, tag)
+	}
+	buf.WriteString("}\n")
+}
+
+func (m *model) writeGoOperation(buf *bytes.Buffer, op *operation) {
+	params, args := "ctx context.Context", "ctx"
+	if op.request != nil {
+		params += ", req *" + op.request.name
+		args += ", req"
+	}
+
+	dataType := ""
+	if op.data != nil {
+		dataType = m.goType(op.data)
+		if op.data.Ref != "" && op.pagination == nil {
+			dataType = "*" + dataType
+		}
+	}
+
+	results := "error"
+	switch {
+	case op.pagination != nil:
+		params += ", page Page"
+		results = fmt.Sprintf("([]%s, *Pagination, error)", dataType)
+	case op.data != nil:
+		results = fmt.Sprintf("(%s, error)", dataType)
+	}
+
+	fmt.Fprintf(buf, "\n// %s %s\n//\n//\t%s %s\n", op.name, firstNonEmpty(op.summary, "call the API"), op.method, op.path)
+	fmt.Fprintf(buf, "func (c *Client) %s(%s) %s {\n", op.name, params, results)
+	if op.request != nil {
+		fmt.Fprintf(buf, "\tif req == nil {\n\t\treq = &%s{}\n\t}\n", op.request.name)
+	}
+
+	buf.WriteString("\tquery, header := url.Values{}, http.Header{}\n")
+	fmt.Fprintf(buf, "\tpath := %s\n", m.goPath(op))
+	if op.request != nil {
+		for _, field := range op.request.fields {
+			m.writeGoParam(buf, field)
+		}
+	}
+
+	body := "nil"
+	if op.hasBody {
+		body = "req"
+	}
+	switch {
+	case op.pagination != nil:
+		fmt.Fprintf(buf, "\tpage.values(query, %q, %q)\n", op.pagination.StartParam, op.pagination.LimitParam)
+		fmt.Fprintf(buf, "\tvar data []%s\n", dataType)
+		fmt.Fprintf(buf, "\tpagination, err := c.do(ctx, %q, path, query, header, %s, &data)\n", op.method, body)
+		buf.WriteString("\treturn data, pagination, err\n}\n")
+
+		fmt.Fprintf(buf, "\n// %sIter iterate the items of all the pages of %s, limit is the page size and zero means the default size\n", op.name, op.name)
+		fmt.Fprintf(buf, "func (c *Client) %sIter(%s, limit uint64) *Iterator[%s] {\n", op.name, strings.TrimSuffix(params, ", page Page"), dataType)
+		fmt.Fprintf(buf, "\treturn newIterator(ctx, %q, %q, limit, func(ctx context.Context, page Page) ([]%s, *Pagination, error) {\n", op.pagination.Kind, op.pagination.StartParam, dataType)
+		fmt.Fprintf(buf, "\t\treturn c.%s(%s, page)\n\t})\n}\n", op.name, args)
+
+	case op.data != nil:
+		fmt.Fprintf(buf, "\tvar data %s\n", dataType)
+		fmt.Fprintf(buf, "\t_, err := c.do(ctx, %q, path, query, header, %s, &data)\n", op.method, body)
+		buf.WriteString("\treturn data, err\n}\n")
+
+	default:
+		fmt.Fprintf(buf, "\t_, err := c.do(ctx, %q, path, query, header, %s, nil)\n", op.method, body)
+		buf.WriteString("\treturn err\n}\n")
+	}
+}
+
+// goPath return the expression of the path with the path params escaped
+func (m *model) goPath(op *operation) string {
+	var parts []string
+	for _, segment := range pathSegments(op.path) {
+		if !strings.HasPrefix(segment, "{") {
+			parts = append(parts, strconv.Quote(segment))
+			continue
+		}
+
+		field := op.pathField(strings.Trim(segment, "{}"))
+		if field == nil {
+			parts = append(parts, strconv.Quote(segment))
+			continue
+		}
+		value := "req." + field.name
+		if !field.required {
+			value = "*" + value
+		}
+		parts = append(parts, "url.PathEscape(formatParam("+value+"))")
+	}
+	if len(parts) == 0 {
+		return `""`
+	}
+	return strings.Join(parts, " + ")
+}
+
+// pathField return the request field of the path param
+func (op *operation) pathField(name string) *fieldDef {
+	if op.request == nil {
+		return nil
+	}
+	for _, field := range op.request.fields {
+		if field.in == "path" && field.jsonName == name {
+			return field
+		}
+	}
+	return nil
+}
+
+func (m *model) writeGoParam(buf *bytes.Buffer, field *fieldDef) {
+	var target string
+	switch field.in {
+	case "query":
+		target = "query"
+	case "header":
+		target = "header"
+	default:
+		return
+	}
+
+	typ := m.goType(field.schema)
+	switch {
+	case strings.HasPrefix(typ, "[]") && typ != "[]byte":
+		fmt.Fprintf(buf, "\tfor _, v := range req.%s {\n\t\t%s.Add(%q, formatParam(v))\n\t}\n", field.name, target, field.jsonName)
+	case field.required:
+		fmt.Fprintf(buf, "\t%s.Set(%q, formatParam(req.%s))\n", target, field.jsonName, field.name)
+	default:
+		fmt.Fprintf(buf, "\tif req.%s != nil {\n\t\t%s.Set(%q, formatParam(*req.%s))\n\t}\n", field.name, target, field.jsonName, field.name)
+	}
+}
+
+// goType return the Go type of the schema
+func (m *model) goType(schema *openapi.Schema) string {
+	if schema.Ref != "" {
+		return m.typeName(schema.Ref)
+	}
+
+	switch schema.Type {
+	case "string":
+		switch schema.Format {
+		case "date-time":
+			return "time.Time"
+		case "byte", "binary":
+			return "[]byte"
+		}
+		return "string"
+	case "integer":
+		switch schema.Format {
+		case "int32":
+			if schema.Minimum != nil && *schema.Minimum >= 0 {
+				return "uint32"
+			}
+			return "int32"
+		case "uint64":
+			return "uint64"
+		}
+		return "int64"
+	case "number":
+		if schema.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		if schema.Items == nil {
+			return "[]interface{}"
+		}
+		return "[]" + m.goType(schema.Items)
+	case "object":
+		if schema.AdditionalProperties == nil {
+			return "map[string]interface{}"
+		}
+		return "map[string]" + m.goType(schema.AdditionalProperties)
+	}
+	return "interface{}"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// goRuntime is the part of the Go client independent of the API, it is formatted with the title and the package
+const goRuntime = `// Code generated by clientgen. DO NOT EDIT.
+
+// Package %[2]s is the client of the %[1]s API
+package %[2]s
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client call the API, it unwraps the data from the response envelope and returns the error responses as *Error
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Header is added to every request, such as the authentication headers
+	Header http.Header
+}
+
+// NewClient return a client of the API served at the base URL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL, HTTPClient: http.DefaultClient, Header: http.Header{}}
+}
+
+// FieldError describe a field of the request which failed binding or validation
+type FieldError struct {
+	Field   string ` + "`json:\"field\"`" + `
+	Path    string ` + "`json:\"path\"`" + `
+	Source  string ` + "`json:\"source,omitempty\"`" + `
+	Rule    string ` + "`json:\"rule\"`" + `
+	Param   string ` + "`json:\"param,omitempty\"`" + `
+	Message string ` + "`json:\"message\"`" + `
+}
+
+// Error is the error response of the API
+type Error struct {
+	Status int
+	Code   int
+	Msg    string
+	Errors []FieldError
+}
+
+// Error satisfies the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("api error %%d: %%s", e.Code, e.Msg)
+}
+
+// Is report whether the target is an *Error of the same code, such as errors.Is(err, ErrNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// CursorPagination is the cursors of the cursor pagination
+type CursorPagination struct {
+	Limit uint64 ` + "`json:\"limit\"`" + `
+	Next  string ` + "`json:\"next,omitempty\"`" + `
+	Prev  string ` + "`json:\"prev,omitempty\"`" + `
+}
+
+// Links is the links of the pages
+type Links struct {
+	Self  string ` + "`json:\"self,omitempty\"`" + `
+	First string ` + "`json:\"first,omitempty\"`" + `
+	Prev  string ` + "`json:\"prev,omitempty\"`" + `
+	Next  string ` + "`json:\"next,omitempty\"`" + `
+	Last  string ` + "`json:\"last,omitempty\"`" + `
+}
+
+// Pagination is the pagination of the response
+type Pagination struct {
+	Start  uint64            ` + "`json:\"start\"`" + `
+	Limit  uint64            ` + "`json:\"limit\"`" + `
+	Total  uint64            ` + "`json:\"total,omitempty\"`" + `
+	Cursor *CursorPagination ` + "`json:\"cursor,omitempty\"`" + `
+	Links  Links             ` + "`json:\"_links\"`" + `
+}
+
+// Page is the page to request, Start is the start param or the cursor, the defaults of the API are used if they are empty
+type Page struct {
+	Start string
+	Limit uint64
+}
+
+func (p Page) values(query url.Values, startParam, limitParam string) {
+	if p.Start != "" {
+		query.Set(startParam, p.Start)
+	}
+	if p.Limit > 0 {
+		query.Set(limitParam, fmt.Sprint(p.Limit))
+	}
+}
+
+// Iterator iterate the items of all the pages, the next page is requested when the items of the current page are consumed
+type Iterator[T any] struct {
+	ctx        context.Context
+	kind       string
+	startParam string
+	page       Page
+	fetch      func(ctx context.Context, page Page) ([]T, *Pagination, error)
+
+	items []T
+	item  T
+	done  bool
+	err   error
+}
+
+func newIterator[T any](ctx context.Context, kind, startParam string, limit uint64, fetch func(ctx context.Context, page Page) ([]T, *Pagination, error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, kind: kind, startParam: startParam, page: Page{Limit: limit}, fetch: fetch}
+}
+
+// Next advance to the next item, it returns false when the items are exhausted or an error occurs
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		items, pagination, err := it.fetch(it.ctx, it.page)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.items = items
+		it.page.Start = nextStart(pagination, it.kind, it.startParam)
+		it.done = len(items) == 0 || it.page.Start == ""
+	}
+
+	it.item, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Item return the current item
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err return the error which stopped the iteration
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// nextStart return the start param of the next page, it is empty if there is no next page
+func nextStart(pagination *Pagination, kind, startParam string) string {
+	if pagination == nil {
+		return ""
+	}
+	if kind == "cursor" {
+		if pagination.Cursor == nil {
+			return ""
+		}
+		return pagination.Cursor.Next
+	}
+
+	next, err := url.Parse(pagination.Links.Next)
+	if err != nil || pagination.Links.Next == "" {
+		return ""
+	}
+	return next.Query().Get(startParam)
+}
+
+type envelope struct {
+	Code       int             ` + "`json:\"code\"`" + `
+	Msg        string          ` + "`json:\"msg\"`" + `
+	Data       json.RawMessage ` + "`json:\"data\"`" + `
+	Errors     []FieldError    ` + "`json:\"errors\"`" + `
+	Pagination *Pagination     ` + "`json:\"pagination\"`" + `
+}
+
+// do send the request, decode the data of the response into data, and return the pagination of the response
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body, data interface{}) (*Pagination, error) {
+	target := strings.TrimRight(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return nil, &Error{Status: resp.StatusCode, Msg: strings.TrimSpace(string(b))}
+	}
+	if env.Code != http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &Error{Status: resp.StatusCode, Code: env.Code, Msg: env.Msg, Errors: env.Errors}
+	}
+
+	if data != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, data); err != nil {
+			return nil, err
+		}
+	}
+	return env.Pagination, nil
+}
+
+// formatParam format the value of the path, query or header param
+func formatParam(v interface{}) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+`
@@ -0,0 +1,101 @@
+// Package testapi is the API the generated clients are tested against
+package testapi
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrOrderNotFound is responded when the order does not exist
+var ErrOrderNotFound = errors.New("order not found")
+
+// Order is an order of the API
+type Order struct {
+	ID     uint64   `json:"id"`
+	Amount uint64   `json:"amount"`
+	Memo   string   `json:"memo,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// GetOrderReq is the request of getting an order
+type GetOrderReq struct {
+	ID    uint64 `uri:"id" json:"-"`
+	Token string `header:"X-Token" json:"-"`
+}
+
+// CreateOrderReq is the request of creating an order
+type CreateOrderReq struct {
+	Amount uint64   `json:"amount" binding:"required,gt=0"`
+	Memo   string   `json:"memo"`
+	Tags   []string `json:"tags"`
+	DryRun bool     `json:"-" query:"dry_run"`
+}
+
+// ListOrdersReq is the request of listing the orders
+type ListOrdersReq struct {
+	MinAmount uint64 `json:"-" query:"min_amount"`
+}
+
+// New return the engine serving the API, and the handler recording the routes of it
+func New(orders []Order) (*gin.Engine, *handler.Handler) {
+	h := handler.NewHandler(map[error]int{ErrOrderNotFound: 40400}, nil, nil).SetValidationErrCode(40000)
+	engine := gin.New()
+
+	g := h.Group(engine, "/v1/orders")
+	g.GET("/:id", func(c *gin.Context, req *GetOrderReq) (*Order, error) {
+		for _, order := range orders {
+			if order.ID == req.ID && req.Token == "secret" {
+				return &order, nil
+			}
+		}
+		return nil, ErrOrderNotFound
+	}, handler.WithName("getOrder"), handler.WithSummary("get an order by id"))
+
+	handler.HandleJSON(g, http.MethodPost, "", func(c *gin.Context, req *CreateOrderReq) (*Order, error) {
+		order := Order{ID: uint64(len(orders) + 1), Amount: req.Amount, Memo: req.Memo, Tags: req.Tags}
+		if !req.DryRun {
+			orders = append(orders, order)
+		}
+		return &order, nil
+	}, handler.WithName("createOrder"))
+
+	handler.HandlePaginated(g, http.MethodGet, "", func(c *gin.Context, req *ListOrdersReq, query *handler.PaginationQuery) ([]Order, uint64, error) {
+		var matched []Order
+		for _, order := range orders {
+			if order.Amount >= req.MinAmount {
+				matched = append(matched, order)
+			}
+		}
+
+		start, end := query.Start, query.Start+query.Limit
+		if start > uint64(len(matched)) {
+			start = uint64(len(matched))
+		}
+		if end > uint64(len(matched)) {
+			end = uint64(len(matched))
+		}
+		return matched[start:end], uint64(len(matched)), nil
+	}, handler.WithName("listOrders"))
+
+	handler.HandleCursorPaginated(h.Group(engine, "/v1"), http.MethodGet, "/feed", func(c *gin.Context, req *handler.Empty, query *handler.CursorQuery) ([]Order, handler.Cursor, handler.Cursor, error) {
+		start := 0
+		if after, ok := query.After.String("id"); ok {
+			start, _ = strconv.Atoi(after)
+		}
+
+		end := start + int(query.Limit)
+		if end >= len(orders) {
+			return orders[start:], nil, nil, nil
+		}
+		return orders[start:end], handler.Cursor{"id": strconv.Itoa(end)}, nil, nil
+	}, handler.WithName("orderFeed"))
+
+	g.DELETE("/:id", func(c *gin.Context, req *GetOrderReq) error {
+		return ErrOrderNotFound
+	}, handler.WithName("deleteOrder"))
+	return engine, h
+}
@@ -0,0 +1,377 @@
+// Code generated by clientgen. DO NOT EDIT.
+
+// Package testclient is the client of the orders API
+package testclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client call the API, it unwraps the data from the response envelope and returns the error responses as *Error
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Header is added to every request, such as the authentication headers
+	Header http.Header
+}
+
+// NewClient return a client of the API served at the base URL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL, HTTPClient: http.DefaultClient, Header: http.Header{}}
+}
+
+// FieldError describe a field of the request which failed binding or validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Path    string `json:"path"`
+	Source  string `json:"source,omitempty"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error is the error response of the API
+type Error struct {
+	Status int
+	Code   int
+	Msg    string
+	Errors []FieldError
+}
+
+// Error satisfies the error interface
+func (e *Error) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.Code, e.Msg)
+}
+
+// Is report whether the target is an *Error of the same code, such as errors.Is(err, ErrNotFound)
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// CursorPagination is the cursors of the cursor pagination
+type CursorPagination struct {
+	Limit uint64 `json:"limit"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+// Links is the links of the pages
+type Links struct {
+	Self  string `json:"self,omitempty"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+	Last  string `json:"last,omitempty"`
+}
+
+// Pagination is the pagination of the response
+type Pagination struct {
+	Start  uint64            `json:"start"`
+	Limit  uint64            `json:"limit"`
+	Total  uint64            `json:"total,omitempty"`
+	Cursor *CursorPagination `json:"cursor,omitempty"`
+	Links  Links             `json:"_links"`
+}
+
+// Page is the page to request, Start is the start param or the cursor, the defaults of the API are used if they are empty
+type Page struct {
+	Start string
+	Limit uint64
+}
+
+func (p Page) values(query url.Values, startParam, limitParam string) {
+	if p.Start != "" {
+		query.Set(startParam, p.Start)
+	}
+	if p.Limit > 0 {
+		query.Set(limitParam, fmt.Sprint(p.Limit))
+	}
+}
+
+// Iterator iterate the items of all the pages, the next page is requested when the items of the current page are consumed
+type Iterator[T any] struct {
+	ctx        context.Context
+	kind       string
+	startParam string
+	page       Page
+	fetch      func(ctx context.Context, page Page) ([]T, *Pagination, error)
+
+	items []T
+	item  T
+	done  bool
+	err   error
+}
+
+func newIterator[T any](ctx context.Context, kind, startParam string, limit uint64, fetch func(ctx context.Context, page Page) ([]T, *Pagination, error)) *Iterator[T] {
+	return &Iterator[T]{ctx: ctx, kind: kind, startParam: startParam, page: Page{Limit: limit}, fetch: fetch}
+}
+
+// Next advance to the next item, it returns false when the items are exhausted or an error occurs
+func (it *Iterator[T]) Next() bool {
+	for len(it.items) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		items, pagination, err := it.fetch(it.ctx, it.page)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.items = items
+		it.page.Start = nextStart(pagination, it.kind, it.startParam)
+		it.done = len(items) == 0 || it.page.Start == ""
+	}
+
+	it.item, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Item return the current item
+func (it *Iterator[T]) Item() T {
+	return it.item
+}
+
+// Err return the error which stopped the iteration
+func (it *Iterator[T]) Err() error {
+	return it.err
+}
+
+// nextStart return the start param of the next page, it is empty if there is no next page
+func nextStart(pagination *Pagination, kind, startParam string) string {
+	if pagination == nil {
+		return ""
+	}
+	if kind == "cursor" {
+		if pagination.Cursor == nil {
+			return ""
+		}
+		return pagination.Cursor.Next
+	}
+
+	next, err := url.Parse(pagination.Links.Next)
+	if err != nil || pagination.Links.Next == "" {
+		return ""
+	}
+	return next.Query().Get(startParam)
+}
+
+type envelope struct {
+	Code       int             `json:"code"`
+	Msg        string          `json:"msg"`
+	Data       json.RawMessage `json:"data"`
+	Errors     []FieldError    `json:"errors"`
+	Pagination *Pagination     `json:"pagination"`
+}
+
+// do send the request, decode the data of the response into data, and return the pagination of the response
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, body, data interface{}) (*Pagination, error) {
+	target := strings.TrimRight(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var env envelope
+	if err := json.Unmarshal(b, &env); err != nil {
+		return nil, &Error{Status: resp.StatusCode, Msg: strings.TrimSpace(string(b))}
+	}
+	if env.Code != http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, &Error{Status: resp.StatusCode, Code: env.Code, Msg: env.Msg, Errors: env.Errors}
+	}
+
+	if data != nil && len(env.Data) > 0 {
+		if err := json.Unmarshal(env.Data, data); err != nil {
+			return nil, err
+		}
+	}
+	return env.Pagination, nil
+}
+
+// formatParam format the value of the path, query or header param
+func formatParam(v interface{}) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
+
+// the errors of the API, the errors returned by the client match them by errors.Is
+var (
+	ErrValidation    = &Error{Status: 400, Code: 40000, Msg: "validation error"}
+	ErrOrderNotFound = &Error{Code: 40400, Msg: "order not found"}
+)
+
+// Order is a schema of the API
+type Order struct {
+	Amount uint64   `json:"amount,omitempty"`
+	ID     uint64   `json:"id,omitempty"`
+	Memo   string   `json:"memo,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+}
+
+// OrderFeed call the API
+//
+//	GET /v1/feed
+func (c *Client) OrderFeed(ctx context.Context, page Page) ([]Order, *Pagination, error) {
+	query, header := url.Values{}, http.Header{}
+	path := "/v1/feed"
+	page.values(query, "cursor", "limit")
+	var data []Order
+	pagination, err := c.do(ctx, "GET", path, query, header, nil, &data)
+	return data, pagination, err
+}
+
+// OrderFeedIter iterate the items of all the pages of OrderFeed, limit is the page size and zero means the default size
+func (c *Client) OrderFeedIter(ctx context.Context, limit uint64) *Iterator[Order] {
+	return newIterator(ctx, "cursor", "cursor", limit, func(ctx context.Context, page Page) ([]Order, *Pagination, error) {
+		return c.OrderFeed(ctx, page)
+	})
+}
+
+// ListOrdersRequest is the request of ListOrders
+type ListOrdersRequest struct {
+	MinAmount *uint64 `json:"-"` // query min_amount
+}
+
+// ListOrders call the API
+//
+//	GET /v1/orders
+func (c *Client) ListOrders(ctx context.Context, req *ListOrdersRequest, page Page) ([]Order, *Pagination, error) {
+	if req == nil {
+		req = &ListOrdersRequest{}
+	}
+	query, header := url.Values{}, http.Header{}
+	path := "/v1/orders"
+	if req.MinAmount != nil {
+		query.Set("min_amount", formatParam(*req.MinAmount))
+	}
+	page.values(query, "start", "limit")
+	var data []Order
+	pagination, err := c.do(ctx, "GET", path, query, header, nil, &data)
+	return data, pagination, err
+}
+
+// ListOrdersIter iterate the items of all the pages of ListOrders, limit is the page size and zero means the default size
+func (c *Client) ListOrdersIter(ctx context.Context, req *ListOrdersRequest, limit uint64) *Iterator[Order] {
+	return newIterator(ctx, "offset", "start", limit, func(ctx context.Context, page Page) ([]Order, *Pagination, error) {
+		return c.ListOrders(ctx, req, page)
+	})
+}
+
+// CreateOrderRequest is the request of CreateOrder
+type CreateOrderRequest struct {
+	Amount uint64   `json:"amount"`
+	Memo   string   `json:"memo,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	DryRun *bool    `json:"-"` // query dry_run
+}
+
+// CreateOrder call the API
+//
+//	POST /v1/orders
+func (c *Client) CreateOrder(ctx context.Context, req *CreateOrderRequest) (*Order, error) {
+	if req == nil {
+		req = &CreateOrderRequest{}
+	}
+	query, header := url.Values{}, http.Header{}
+	path := "/v1/orders"
+	if req.DryRun != nil {
+		query.Set("dry_run", formatParam(*req.DryRun))
+	}
+	var data *Order
+	_, err := c.do(ctx, "POST", path, query, header, req, &data)
+	return data, err
+}
+
+// GetOrderRequest is the request of GetOrder
+type GetOrderRequest struct {
+	ID     uint64  `json:"-"` // path id
+	XToken *string `json:"-"` // header X-Token
+}
+
+// GetOrder get an order by id
+//
+//	GET /v1/orders/{id}
+func (c *Client) GetOrder(ctx context.Context, req *GetOrderRequest) (*Order, error) {
+	if req == nil {
+		req = &GetOrderRequest{}
+	}
+	query, header := url.Values{}, http.Header{}
+	path := "/v1/orders/" + url.PathEscape(formatParam(req.ID))
+	if req.XToken != nil {
+		header.Set("X-Token", formatParam(*req.XToken))
+	}
+	var data *Order
+	_, err := c.do(ctx, "GET", path, query, header, nil, &data)
+	return data, err
+}
+
+// DeleteOrderRequest is the request of DeleteOrder
+type DeleteOrderRequest struct {
+	ID     uint64  `json:"-"` // path id
+	XToken *string `json:"-"` // header X-Token
+}
+
+// DeleteOrder call the API
+//
+//	DELETE /v1/orders/{id}
+func (c *Client) DeleteOrder(ctx context.Context, req *DeleteOrderRequest) error {
+	if req == nil {
+		req = &DeleteOrderRequest{}
+	}
+	query, header := url.Values{}, http.Header{}
+	path := "/v1/orders/" + url.PathEscape(formatParam(req.ID))
+	if req.XToken != nil {
+		header.Set("X-Token", formatParam(*req.XToken))
+	}
+	_, err := c.do(ctx, "DELETE", path, query, header, nil, nil)
+	return err
+}
@@ -0,0 +1,86 @@
+package testclient
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bytom/community/gintools/clientgen/internal/testapi"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newTestClient(t *testing.T) *Client {
+	var orders []testapi.Order
+	for i := uint64(1); i <= 5; i++ {
+		orders = append(orders, testapi.Order{ID: i, Amount: i * 10})
+	}
+
+	engine, _ := testapi.New(orders)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL)
+}
+
+func TestClient(t *testing.T) {
+	client, ctx := newTestClient(t), context.Background()
+
+	token := "secret"
+	order, err := client.GetOrder(ctx, &GetOrderRequest{ID: 2, XToken: &token})
+	if err != nil || order.ID != 2 || order.Amount != 20 {
+		t.Fatalf("GetOrder() = %+v, %v", order, err)
+	}
+
+	dryRun := true
+	created, err := client.CreateOrder(ctx, &CreateOrderRequest{Amount: 7, Memo: "hi", DryRun: &dryRun})
+	if err != nil || created.ID != 6 || created.Memo != "hi" {
+		t.Errorf("CreateOrder() = %+v, %v", created, err)
+	}
+
+	orders, pagination, err := client.ListOrders(ctx, nil, Page{Limit: 2})
+	if err != nil || len(orders) != 2 || pagination.Total != 5 {
+		t.Errorf("ListOrders() = %+v, %+v, %v", orders, pagination, err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	client, ctx := newTestClient(t), context.Background()
+
+	_, err := client.GetOrder(ctx, &GetOrderRequest{ID: 9})
+	if !errors.Is(err, ErrOrderNotFound) || errors.Is(err, ErrValidation) {
+		t.Errorf("GetOrder() error = %v, want ErrOrderNotFound", err)
+	}
+
+	_, err = client.CreateOrder(ctx, &CreateOrderRequest{})
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || !errors.Is(err, ErrValidation) || len(apiErr.Errors) != 1 || apiErr.Errors[0].Path != "amount" {
+		t.Errorf("CreateOrder() error = %+v, want the validation error of amount", err)
+	}
+}
+
+func TestClientIterator(t *testing.T) {
+	client, ctx := newTestClient(t), context.Background()
+
+	minAmount := uint64(20)
+	var ids []uint64
+	it := client.ListOrdersIter(ctx, &ListOrdersRequest{MinAmount: &minAmount}, 2)
+	for it.Next() {
+		ids = append(ids, it.Item().ID)
+	}
+	if it.Err() != nil || len(ids) != 4 || ids[0] != 2 || ids[3] != 5 {
+		t.Errorf("ListOrdersIter() = %v, %v, want the orders 2 to 5", ids, it.Err())
+	}
+
+	ids = nil
+	feed := client.OrderFeedIter(ctx, 2)
+	for feed.Next() {
+		ids = append(ids, feed.Item().ID)
+	}
+	if feed.Err() != nil || len(ids) != 5 {
+		t.Errorf("OrderFeedIter() = %v, %v, want all the 5 orders", ids, feed.Err())
+	}
+}
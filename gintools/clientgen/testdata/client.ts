@@ -0,0 +1,250 @@
+// Code generated by clientgen. DO NOT EDIT.
+// The client of the orders API.
+
+export interface FieldError {
+  field: string;
+  path: string;
+  source?: string;
+  rule: string;
+  param?: string;
+  message: string;
+}
+
+export interface CursorPagination {
+  limit: number;
+  next?: string;
+  prev?: string;
+}
+
+export interface Links {
+  self?: string;
+  first?: string;
+  prev?: string;
+  next?: string;
+  last?: string;
+}
+
+export interface Pagination {
+  start?: number;
+  limit?: number;
+  total?: number;
+  cursor?: CursorPagination;
+  _links: Links;
+}
+
+/** The items of a page and the pagination of it. */
+export interface Page<T> {
+  items: T[];
+  pagination?: Pagination;
+}
+
+/** The page to request, start is the start param or the cursor, the defaults of the API are used if they are absent. */
+export interface PageParams {
+  start?: string;
+  limit?: number;
+}
+
+/** The error response of the API, the errors of the known codes are thrown as the sub-classes of it. */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: number,
+    message: string,
+    readonly errors: FieldError[] = [],
+  ) {
+    super(message);
+    this.name = new.target.name;
+  }
+}
+
+/** validation error */
+export class ValidationError extends ApiError {
+  static readonly code = 40000;
+}
+
+/** order not found */
+export class OrderNotFoundError extends ApiError {
+  static readonly code = 40400;
+}
+
+const errorTypes: { [code: number]: typeof ApiError } = {
+  40000: ValidationError,
+  40400: OrderNotFoundError,
+};
+
+export interface Order {
+  amount?: number | bigint;
+  id?: number | bigint;
+  memo?: string;
+  tags?: string[];
+}
+
+export interface ListOrdersRequest {
+  minAmount?: number | bigint;
+}
+
+export interface CreateOrderRequest {
+  amount: number | bigint;
+  memo?: string;
+  tags?: string[];
+  dryRun?: boolean;
+}
+
+export interface GetOrderRequest {
+  id: number | bigint;
+  xToken?: string;
+}
+
+export interface DeleteOrderRequest {
+  id: number | bigint;
+  xToken?: string;
+}
+
+interface Envelope {
+  code: number;
+  msg: string;
+  data?: unknown;
+  errors?: FieldError[];
+  pagination?: Pagination;
+}
+
+export interface ClientOptions {
+  baseURL: string;
+  /** The headers added to every request, such as the authentication headers. */
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+function formatParam(v: unknown): string {
+  return v instanceof Date ? v.toISOString() : String(v);
+}
+
+const unsafeInteger = /"(?:[^"\\]|\\.)*"|-?\d{16,}(?![\d.eE])/g;
+
+/** Parse the JSON, the integers beyond the safe range of number are parsed as bigint to keep their precision. */
+function parseJSON(text: string): any {
+  const marked = text.replace(unsafeInteger, (token) => (token[0] === '"' ? token : '"\\u0000' + token + '"'));
+  return JSON.parse(marked, (_, v) => {
+    if (typeof v !== "string" || !/^\u0000-?\d+$/.test(v)) return v;
+    const n = BigInt(v.slice(1));
+    return Number.isSafeInteger(Number(n)) ? Number(n) : n;
+  });
+}
+
+/** Stringify the value as JSON, the bigint values are written as numbers. */
+function stringifyJSON(value: unknown): string {
+  return JSON.stringify(value, (_, v) => (typeof v === "bigint" ? "\u0000" + v : v)).replace(/"\\u0000(-?\d+)"/g, "$1");
+}
+
+function nextStart(pagination: Pagination | undefined, kind: string, startParam: string): string | undefined {
+  if (kind === "cursor") return pagination?.cursor?.next || undefined;
+  const next = pagination?._links?.next;
+  if (!next) return undefined;
+  return new URL(next, "http://localhost").searchParams.get(startParam) ?? undefined;
+}
+
+export class Client {
+  constructor(private readonly options: ClientOptions) {}
+
+  private async request<T>(
+    method: string,
+    path: string,
+    query: URLSearchParams,
+    headers: Record<string, string>,
+    body?: unknown,
+  ): Promise<{ data: T; pagination?: Pagination }> {
+    const search = query.toString();
+    const url = this.options.baseURL.replace(/\/+$/, "") + path + (search ? "?" + search : "");
+    const init: RequestInit = { method, headers: { ...this.options.headers, ...headers } };
+    if (body !== undefined) {
+      init.body = stringifyJSON(body);
+      (init.headers as Record<string, string>)["Content-Type"] = "application/json";
+    }
+
+    const resp = await (this.options.fetch ?? fetch)(url, init);
+    const text = await resp.text();
+    let envelope: Envelope;
+    try {
+      envelope = parseJSON(text);
+    } catch {
+      throw new ApiError(resp.status, 0, text);
+    }
+
+    if (envelope.code !== 200 || !resp.ok) {
+      const ErrorType = errorTypes[envelope.code] ?? ApiError;
+      throw new ErrorType(resp.status, envelope.code, envelope.msg, envelope.errors);
+    }
+    return { data: envelope.data as T, pagination: envelope.pagination };
+  }
+
+  /** OrderFeed: GET /v1/feed */
+  async orderFeed(page: PageParams = {}): Promise<Page<Order>> {
+    const query = new URLSearchParams();
+    const headers: Record<string, string> = {};
+    if (page.start !== undefined) query.set("cursor", page.start);
+    if (page.limit !== undefined) query.set("limit", String(page.limit));
+    const { data, pagination } = await this.request<Order[]>("GET", `/v1/feed`, query, headers);
+    return { items: data ?? [], pagination };
+  }
+
+  /** Iterate the items of all the pages of orderFeed. */
+  async *orderFeedIter(limit?: number): AsyncGenerator<Order> {
+    let page: PageParams = { limit };
+    for (;;) {
+      const { items, pagination } = await this.orderFeed(page);
+      yield* items;
+      const start = nextStart(pagination, "cursor", "cursor");
+      if (items.length === 0 || start === undefined) return;
+      page = { start, limit };
+    }
+  }
+
+  /** ListOrders: GET /v1/orders */
+  async listOrders(req: ListOrdersRequest = {}, page: PageParams = {}): Promise<Page<Order>> {
+    const query = new URLSearchParams();
+    const headers: Record<string, string> = {};
+    if (req.minAmount !== undefined) query.set("min_amount", formatParam(req.minAmount));
+    if (page.start !== undefined) query.set("start", page.start);
+    if (page.limit !== undefined) query.set("limit", String(page.limit));
+    const { data, pagination } = await this.request<Order[]>("GET", `/v1/orders`, query, headers);
+    return { items: data ?? [], pagination };
+  }
+
+  /** Iterate the items of all the pages of listOrders. */
+  async *listOrdersIter(req: ListOrdersRequest = {}, limit?: number): AsyncGenerator<Order> {
+    let page: PageParams = { limit };
+    for (;;) {
+      const { items, pagination } = await this.listOrders(req, page);
+      yield* items;
+      const start = nextStart(pagination, "offset", "start");
+      if (items.length === 0 || start === undefined) return;
+      page = { start, limit };
+    }
+  }
+
+  /** CreateOrder: POST /v1/orders */
+  async createOrder(req: CreateOrderRequest): Promise<Order> {
+    const query = new URLSearchParams();
+    const headers: Record<string, string> = {};
+    if (req.dryRun !== undefined) query.set("dry_run", formatParam(req.dryRun));
+    const { data } = await this.request<Order>("POST", `/v1/orders`, query, headers, { amount: req.amount, memo: req.memo, tags: req.tags });
+    return data;
+  }
+
+  /** get an order by id: GET /v1/orders/{id} */
+  async getOrder(req: GetOrderRequest): Promise<Order> {
+    const query = new URLSearchParams();
+    const headers: Record<string, string> = {};
+    if (req.xToken !== undefined) headers["X-Token"] = formatParam(req.xToken);
+    const { data } = await this.request<Order>("GET", `/v1/orders/${encodeURIComponent(formatParam(req.id))}`, query, headers);
+    return data;
+  }
+
+  /** DeleteOrder: DELETE /v1/orders/{id} */
+  async deleteOrder(req: DeleteOrderRequest): Promise<void> {
+    const query = new URLSearchParams();
+    const headers: Record<string, string> = {};
+    if (req.xToken !== undefined) headers["X-Token"] = formatParam(req.xToken);
+    await this.request<void>("DELETE", `/v1/orders/${encodeURIComponent(formatParam(req.id))}`, query, headers);
+  }
+}
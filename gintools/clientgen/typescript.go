@@ -0,0 +1,381 @@
+package clientgen
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bytom/community/gintools/openapi"
+)
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// GenerateTypeScript return the source of the TypeScript client of the document
+func GenerateTypeScript(doc *openapi.Document) ([]byte, error) {
+	m, err := newModel(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by clientgen. DO NOT EDIT.\n// The client of the %s API.\n", doc.Info.Title)
+	buf.WriteString(tsRuntime)
+
+	for _, def := range m.errors {
+		fmt.Fprintf(buf, "\n/** %s */\nexport class %sError extends ApiError {\n  static readonly code = %d;\n}\n", def.message, def.name, def.code)
+	}
+	buf.WriteString("\nconst errorTypes: { [code: number]: typeof ApiError } = {\n")
+	for _, def := range m.errors {
+		fmt.Fprintf(buf, "  %d: %sError,\n", def.code, def.name)
+	}
+	buf.WriteString("};\n")
+
+	for _, def := range m.types {
+		m.writeTSType(buf, def)
+	}
+	for _, op := range m.operations {
+		if op.request != nil {
+			m.writeTSType(buf, op.request)
+		}
+	}
+
+	buf.WriteString(tsClient)
+	for _, op := range m.operations {
+		m.writeTSOperation(buf, op)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+func (m *model) writeTSType(buf *bytes.Buffer, def *typeDef) {
+	fmt.Fprintf(buf, "\nexport interface %s {\n", def.name)
+	for _, field := range def.fields {
+		optional := "?"
+		if field.required {
+			optional = ""
+		}
+		fmt.Fprintf(buf, "  %s%s: %s;\n", tsProperty(field.tsName()), optional, m.tsType(field.schema))
+	}
+	buf.WriteString("}\n")
+}
+
+// hasRequired report whether any field of the type is required
+func (def *typeDef) hasRequired() bool {
+	for _, field := range def.fields {
+		if field.required {
+			return true
+		}
+	}
+	return false
+}
+
+// tsName return the property name of the field, the params are in camel case and the body fields keep the json names
+func (f *fieldDef) tsName() string {
+	if f.in == "" {
+		return f.jsonName
+	}
+	return camelCase(f.name)
+}
+
+func (m *model) writeTSOperation(buf *bytes.Buffer, op *operation) {
+	name := camelCase(op.name)
+	var params []string
+	if op.request != nil {
+		param := "req: " + op.request.name
+		if !op.request.hasRequired() {
+			param += " = {}"
+		}
+		params = append(params, param)
+	}
+
+	dataType := "void"
+	if op.data != nil {
+		dataType = m.tsType(op.data)
+	}
+
+	fmt.Fprintf(buf, "\n  /** %s: %s %s */\n", firstNonEmpty(op.summary, op.name), op.method, op.path)
+	if op.pagination != nil {
+		fmt.Fprintf(buf, "  async %s(%s): Promise<Page<%s>> {\n", name, strings.Join(append(params, "page: PageParams = {}"), ", "), dataType)
+	} else {
+		fmt.Fprintf(buf, "  async %s(%s): Promise<%s> {\n", name, strings.Join(params, ", "), dataType)
+	}
+
+	buf.WriteString("    const query = new URLSearchParams();\n    const headers: Record<string, string> = {};\n")
+	if op.request != nil {
+		for _, field := range op.request.fields {
+			m.writeTSParam(buf, field)
+		}
+	}
+
+	body := ""
+	if op.hasBody {
+		var fields []string
+		for _, field := range op.request.fields {
+			if field.in == "" {
+				fields = append(fields, fmt.Sprintf("%s: req%s", tsProperty(field.jsonName), tsAccess(field.jsonName)))
+			}
+		}
+		body = ", { " + strings.Join(fields, ", ") + " }"
+	}
+
+	path := m.tsPath(op)
+	switch {
+	case op.pagination == nil && op.data == nil:
+		fmt.Fprintf(buf, "    await this.request<void>(%q, %s, query, headers%s);\n  }\n", op.method, path, body)
+		return
+	case op.pagination == nil:
+		fmt.Fprintf(buf, "    const { data } = await this.request<%s>(%q, %s, query, headers%s);\n    return data;\n  }\n", dataType, op.method, path, body)
+		return
+	}
+
+	fmt.Fprintf(buf, "    if (page.start !== undefined) query.set(%q, page.start);\n", op.pagination.StartParam)
+	fmt.Fprintf(buf, "    if (page.limit !== undefined) query.set(%q, String(page.limit));\n", op.pagination.LimitParam)
+	fmt.Fprintf(buf, "    const { data, pagination } = await this.request<%s[]>(%q, %s, query, headers%s);\n", dataType, op.method, path, body)
+	buf.WriteString("    return { items: data ?? [], pagination };\n  }\n")
+
+	iterParams := append(params, "limit?: number")
+	args := "page"
+	if op.request != nil {
+		args = "req, page"
+	}
+	fmt.Fprintf(buf, "\n  /** Iterate the items of all the pages of %s. */\n", name)
+	fmt.Fprintf(buf, "  async *%sIter(%s): AsyncGenerator<%s> {\n", name, strings.Join(iterParams, ", "), dataType)
+	buf.WriteString("    let page: PageParams = { limit };\n    for (;;) {\n")
+	fmt.Fprintf(buf, "      const { items, pagination } = await this.%s(%s);\n", name, args)
+	buf.WriteString("      yield* items;\n")
+	fmt.Fprintf(buf, "      const start = nextStart(pagination, %q, %q);\n", op.pagination.Kind, op.pagination.StartParam)
+	buf.WriteString("      if (items.length === 0 || start === undefined) return;\n      page = { start, limit };\n    }\n  }\n")
+}
+
+func (m *model) writeTSParam(buf *bytes.Buffer, field *fieldDef) {
+	var set string
+	switch field.in {
+	case "query":
+		set = "query.%s(%q, formatParam(%s))"
+	case "header":
+		set = "headers[%[2]q] = formatParam(%[3]s)"
+	default:
+		return
+	}
+
+	value := "req" + tsAccess(field.tsName())
+	if field.schema.Type == "array" && field.in == "query" {
+		fmt.Fprintf(buf, "    for (const v of %s ?? []) %s;\n", value, fmt.Sprintf(set, "append", field.jsonName, "v"))
+		return
+	}
+	if field.schema.Type == "array" {
+		value += "?.join(\",\")"
+	}
+	stmt := fmt.Sprintf(set, "set", field.jsonName, value)
+	if field.required {
+		fmt.Fprintf(buf, "    %s;\n", stmt)
+		return
+	}
+	fmt.Fprintf(buf, "    if (%s !== undefined) %s;\n", "req"+tsAccess(field.tsName()), stmt)
+}
+
+// tsPath return the template literal of the path with the path params escaped
+func (m *model) tsPath(op *operation) string {
+	var b strings.Builder
+	b.WriteString("`")
+	for _, segment := range pathSegments(op.path) {
+		field := op.pathField(strings.Trim(segment, "{}"))
+		if !strings.HasPrefix(segment, "{") || field == nil {
+			b.WriteString(strings.NewReplacer("`", "\\`", "$", "\\$").Replace(segment))
+			continue
+		}
+		b.WriteString("${encodeURIComponent(formatParam(req" + tsAccess(field.tsName()) + "))}")
+	}
+	b.WriteString("`")
+	return b.String()
+}
+
+// tsType return the TypeScript type of the schema
+func (m *model) tsType(schema *openapi.Schema) string {
+	if schema.Ref != "" {
+		return m.typeName(schema.Ref)
+	}
+
+	switch schema.Type {
+	case "string":
+		return "string"
+	case "integer", "number":
+		// the 64-bit integers beyond the safe range of number are parsed as bigint by parseJSON
+		if schema.Format == "int64" || schema.Format == "uint64" {
+			return "number | bigint"
+		}
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if schema.Items == nil {
+			return "unknown[]"
+		}
+		item := m.tsType(schema.Items)
+		if strings.ContainsAny(item, " |") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if schema.AdditionalProperties == nil {
+			return "Record<string, unknown>"
+		}
+		return "Record<string, " + m.tsType(schema.AdditionalProperties) + ">"
+	}
+	return "unknown"
+}
+
+func tsProperty(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return strconv.Quote(name)
+}
+
+func tsAccess(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return "." + name
+	}
+	return "[" + strconv.Quote(name) + "]"
+}
+
+// tsRuntime is the part of the TypeScript client independent of the API, which precedes the types
+const tsRuntime = `
+export interface FieldError {
+  field: string;
+  path: string;
+  source?: string;
+  rule: string;
+  param?: string;
+  message: string;
+}
+
+export interface CursorPagination {
+  limit: number;
+  next?: string;
+  prev?: string;
+}
+
+export interface Links {
+  self?: string;
+  first?: string;
+  prev?: string;
+  next?: string;
+  last?: string;
+}
+
+export interface Pagination {
+  start?: number;
+  limit?: number;
+  total?: number;
+  cursor?: CursorPagination;
+  _links: Links;
+}
+
+/** The items of a page and the pagination of it. */
+export interface Page<T> {
+  items: T[];
+  pagination?: Pagination;
+}
+
+/** The page to request, start is the start param or the cursor, the defaults of the API are used if they are absent. */
+export interface PageParams {
+  start?: string;
+  limit?: number;
+}
+
+/** The error response of the API, the errors of the known codes are thrown as the sub-classes of it. */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: number,
+    message: string,
+    readonly errors: FieldError[] = [],
+  ) {
+    super(message);
+    this.name = new.target.name;
+  }
+}
+`
+
+// tsClient is the beginning of the client class, the operations are appended to it
+const tsClient = `
+interface Envelope {
+  code: number;
+  msg: string;
+  data?: unknown;
+  errors?: FieldError[];
+  pagination?: Pagination;
+}
+
+export interface ClientOptions {
+  baseURL: string;
+  /** The headers added to every request, such as the authentication headers. */
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+function formatParam(v: unknown): string {
+  return v instanceof Date ? v.toISOString() : String(v);
+}
+
+const unsafeInteger = /"(?:[^"\\]|\\.)*"|-?\d{16,}(?![\d.eE])/g;
+
+/** Parse the JSON, the integers beyond the safe range of number are parsed as bigint to keep their precision. */
+function parseJSON(text: string): any {
+  const marked = text.replace(unsafeInteger, (token) => (token[0] === '"' ? token : '"\\u0000' + token + '"'));
+  return JSON.parse(marked, (_, v) => {
+    if (typeof v !== "string" || !/^\u0000-?\d+$/.test(v)) return v;
+    const n = BigInt(v.slice(1));
+    return Number.isSafeInteger(Number(n)) ? Number(n) : n;
+  });
+}
+
+/** Stringify the value as JSON, the bigint values are written as numbers. */
+function stringifyJSON(value: unknown): string {
+  return JSON.stringify(value, (_, v) => (typeof v === "bigint" ? "\u0000" + v : v)).replace(/"\\u0000(-?\d+)"/g, "$1");
+}
+
+function nextStart(pagination: Pagination | undefined, kind: string, startParam: string): string | undefined {
+  if (kind === "cursor") return pagination?.cursor?.next || undefined;
+  const next = pagination?._links?.next;
+  if (!next) return undefined;
+  return new URL(next, "http://localhost").searchParams.get(startParam) ?? undefined;
+}
+
+export class Client {
+  constructor(private readonly options: ClientOptions) {}
+
+  private async request<T>(
+    method: string,
+    path: string,
+    query: URLSearchParams,
+    headers: Record<string, string>,
+    body?: unknown,
+  ): Promise<{ data: T; pagination?: Pagination }> {
+    const search = query.toString();
+    const url = this.options.baseURL.replace(/\/+$/, "") + path + (search ? "?" + search : "");
+    const init: RequestInit = { method, headers: { ...this.options.headers, ...headers } };
+    if (body !== undefined) {
+      init.body = stringifyJSON(body);
+      (init.headers as Record<string, string>)["Content-Type"] = "application/json";
+    }
+
+    const resp = await (this.options.fetch ?? fetch)(url, init);
+    const text = await resp.text();
+    let envelope: Envelope;
+    try {
+      envelope = parseJSON(text);
+    } catch {
+      throw new ApiError(resp.status, 0, text);
+    }
+
+    if (envelope.code !== 200 || !resp.ok) {
+      const ErrorType = errorTypes[envelope.code] ?? ApiError;
+      throw new ErrorType(resp.status, envelope.code, envelope.msg, envelope.errors);
+    }
+    return { data: envelope.data as T, pagination: envelope.pagination };
+  }
+`
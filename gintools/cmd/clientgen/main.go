@@ -0,0 +1,66 @@
+// Command clientgen generate the typed Go or TypeScript client of an API built with the handler package,
+// from the OpenAPI document served by openapi.Serve, which is the snapshot of the route registry:
+//
+//	curl -s http://localhost:8080/openapi.json > openapi.json
+//	clientgen -lang go -pkg orders -o orders/client.go openapi.json
+//	clientgen -lang ts -o src/client.ts openapi.json
+//
+// The document is read from the standard input if the file is absent or "-".
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/bytom/community/gintools/clientgen"
+)
+
+func main() {
+	lang := flag.String("lang", "go", "the language of the client, go or ts")
+	pkg := flag.String("pkg", "client", "the package name of the Go client")
+	out := flag.String("o", "", "the output file, the standard output is used if it is empty")
+	flag.Parse()
+
+	if err := run(*lang, *pkg, *out, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "clientgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(lang, pkg, out, in string) error {
+	var r io.Reader = os.Stdin
+	if in != "" && in != "-" {
+		f, err := os.Open(in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	doc, err := clientgen.Load(r)
+	if err != nil {
+		return err
+	}
+
+	var src []byte
+	switch lang {
+	case "go":
+		src, err = clientgen.GenerateGo(doc, pkg)
+	case "ts":
+		src, err = clientgen.GenerateTypeScript(doc)
+	default:
+		return fmt.Errorf("unknown language %q", lang)
+	}
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0644)
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+const (
+	// ContextKeyClient represent the key of the authenticated client name which set in gin context
+	ContextKeyClient = handler.ContextKeyClient
+
+	defaultAPIKeyHeader = "X-API-Key"
+)
+
+var (
+	// ErrMissingAPIKey is returned when the request carries no api key
+	ErrMissingAPIKey = errors.New("missing api key")
+	// ErrInvalidAPIKey is returned when the api key is not one of the configured keys
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+// APIKeyOptions is the options of the static api key authentication
+type APIKeyOptions struct {
+	// Header is the header carrying the key, default is X-API-Key
+	Header string
+	// Query is the query param carrying the key, the key is not read from query if it is empty
+	Query string
+	// Keys map the api keys to the names of their clients
+	Keys map[string]string
+}
+
+// APIKey return a front filter which authenticate the request by the static api keys, the key is read from
+// the header first and then the query param. The name of the client is set into the gin context.
+func APIKey(opts APIKeyOptions) handler.FrontFilter {
+	if opts.Header == "" {
+		opts.Header = defaultAPIKeyHeader
+	}
+
+	// the keys are hashed, so the comparisons take the same time whatever the length of the keys
+	digests := make(map[[sha256.Size]byte]string, len(opts.Keys))
+	for key, client := range opts.Keys {
+		digests[sha256.Sum256([]byte(key))] = client
+	}
+
+	return func(c *gin.Context) error {
+		key := c.GetHeader(opts.Header)
+		if key == "" && opts.Query != "" {
+			key = c.Query(opts.Query)
+		}
+		if key == "" {
+			return ErrMissingAPIKey
+		}
+
+		digest := sha256.Sum256([]byte(key))
+		client, matched := "", 0
+		for candidate, name := range digests {
+			if subtle.ConstantTimeCompare(digest[:], candidate[:]) == 1 {
+				client, matched = name, 1
+			}
+		}
+
+		if matched == 0 {
+			return ErrInvalidAPIKey
+		}
+
+		c.Set(ContextKeyClient, client)
+		return nil
+	}
+}
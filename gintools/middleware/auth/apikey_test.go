@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// runFilter run the front filter with the request, and return the gin context after filtering
+func runFilter(filter handler.FrontFilter, r *http.Request) (*gin.Context, error) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = r
+	return c, filter(c)
+}
+
+func TestAPIKey(t *testing.T) {
+	filter := APIKey(APIKeyOptions{Query: "api_key", Keys: map[string]string{"k1": "wallet", "k2": "explorer"}})
+
+	cases := []struct {
+		header string
+		target string
+		client string
+		err    error
+	}{
+		{header: "k1", target: "/", client: "wallet"},
+		{target: "/?api_key=k2", client: "explorer"},
+		{header: "k3", target: "/?api_key=k2", err: ErrInvalidAPIKey},
+		{target: "/", err: ErrMissingAPIKey},
+	}
+
+	for i, cs := range cases {
+		r := httptest.NewRequest(http.MethodGet, cs.target, strings.NewReader(""))
+		if cs.header != "" {
+			r.Header.Set("X-API-Key", cs.header)
+		}
+
+		c, err := runFilter(filter, r)
+		if errors.Root(err) != cs.err {
+			t.Errorf("case %d: error = %v, want %v", i, err, cs.err)
+			continue
+		}
+		if cs.err == nil && c.GetString(ContextKeyClient) != cs.client {
+			t.Errorf("case %d: client = %q, want %q", i, c.GetString(ContextKeyClient), cs.client)
+		}
+	}
+}
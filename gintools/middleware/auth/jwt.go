@@ -0,0 +1,332 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+// ContextKeyClaims represent the key of the verified JWT claims which set in gin context
+const ContextKeyClaims = "auth_jwt_claims"
+
+var (
+	// ErrMissingToken is returned when the request carries no bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken is returned when the token is malformed, its signature mismatched or its claims are rejected
+	ErrInvalidToken = errors.New("invalid bearer token")
+	// ErrTokenExpired is returned when the token is expired
+	ErrTokenExpired = errors.New("bearer token expired")
+)
+
+// the hashes of the signing algorithms by the suffix of their names
+var jwtHashes = map[string]crypto.Hash{
+	"256": crypto.SHA256,
+	"384": crypto.SHA384,
+	"512": crypto.SHA512,
+}
+
+// Claims is the claims of the verified JWT, the numbers are json.Number
+type Claims map[string]interface{}
+
+// Subject return the "sub" claim
+func (c Claims) Subject() string {
+	sub, _ := c["sub"].(string)
+	return sub
+}
+
+// GetClaims return the verified claims set by the JWT front filter
+func GetClaims(c *gin.Context) (Claims, bool) {
+	claims, ok := c.Get(ContextKeyClaims)
+	if !ok {
+		return nil, false
+	}
+
+	result, ok := claims.(Claims)
+	return result, ok
+}
+
+// JWTOptions is the options of the JWT bearer authentication
+type JWTOptions struct {
+	// Keys map the key ids to the verification keys, the key of the empty id is used if the token has no "kid".
+	// The key is []byte for HS256/384/512, *rsa.PublicKey for RS256/384/512 and *ecdsa.PublicKey for ES256/384/512.
+	Keys map[string]interface{}
+	// Algorithms is the allowed algorithms, all the algorithms matching the key type are allowed if it is empty
+	Algorithms []string
+	// Issuer is the required "iss" claim, it is not checked if it is empty
+	Issuer string
+	// Audience is the required value of the "aud" claim, it is not checked if it is empty
+	Audience string
+	// Leeway is the allowed clock skew checking "exp" and "nbf"
+	Leeway time.Duration
+	// Now return the server time, time.Now is used if it is nil
+	Now func() time.Time
+}
+
+// JWT return a front filter which verify the bearer token of the Authorization header, the verified claims
+// are set into the gin context, and the subject is set as the client name
+func JWT(opts JWTOptions) handler.FrontFilter {
+	return func(c *gin.Context) error {
+		authorization := c.GetHeader("Authorization")
+		if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+			return ErrMissingToken
+		}
+
+		claims, err := ParseJWT(strings.TrimSpace(authorization[7:]), opts)
+		if err != nil {
+			return err
+		}
+
+		c.Set(ContextKeyClaims, claims)
+		c.Set(ContextKeyClient, claims.Subject())
+		return nil
+	}
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// ParseJWT verify the signature and the registered claims of the compact JWT, and return its claims
+func ParseJWT(token string, opts JWTOptions) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.Wrap(ErrInvalidToken, "malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, "decode header")
+	}
+
+	if len(opts.Algorithms) != 0 && !contains(opts.Algorithms, header.Alg) {
+		return nil, errors.Wrap(ErrInvalidToken, "algorithm "+header.Alg+" is not allowed")
+	}
+
+	key, ok := opts.Keys[header.Kid]
+	if !ok {
+		return nil, errors.Wrap(ErrInvalidToken, "unknown key id "+header.Kid)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, "decode signature")
+	}
+
+	if err := verifyJWT(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errors.Wrap(ErrInvalidToken, "decode claims")
+	}
+
+	if err := checkClaims(claims, opts); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifyJWT verify the signature by the algorithm, the key must be of the type matching the algorithm,
+// so a public key can not be used as a HMAC secret
+func verifyJWT(alg string, key interface{}, signingInput string, signature []byte) error {
+	if len(alg) != 5 {
+		return errors.Wrap(ErrInvalidToken, "unsupported algorithm "+alg)
+	}
+
+	hash, ok := jwtHashes[alg[2:]]
+	if !ok {
+		return errors.Wrap(ErrInvalidToken, "unsupported algorithm "+alg)
+	}
+
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case []byte:
+		if alg[:2] != "HS" {
+			break
+		}
+
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return errors.Wrap(ErrInvalidToken, "signature mismatched")
+		}
+		return nil
+
+	case *rsa.PublicKey:
+		if alg[:2] != "RS" {
+			break
+		}
+
+		if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+			return errors.Wrap(ErrInvalidToken, "signature mismatched")
+		}
+		return nil
+
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			break
+		}
+
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.Wrap(ErrInvalidToken, "malformed signature")
+		}
+
+		r, s := new(big.Int).SetBytes(signature[:size]), new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.Wrap(ErrInvalidToken, "signature mismatched")
+		}
+		return nil
+	}
+	return errors.Wrap(ErrInvalidToken, "algorithm "+alg+" mismatched the key")
+}
+
+func checkClaims(claims Claims, opts JWTOptions) error {
+	now := time.Now()
+	if opts.Now != nil {
+		now = opts.Now()
+	}
+
+	if exp, ok, err := numericDate(claims, "exp"); err != nil {
+		return err
+	} else if ok && now.After(exp.Add(opts.Leeway)) {
+		return ErrTokenExpired
+	}
+
+	if nbf, ok, err := numericDate(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Add(opts.Leeway).Before(nbf) {
+		return errors.Wrap(ErrInvalidToken, "token is not valid yet")
+	}
+
+	if opts.Issuer != "" && claims["iss"] != opts.Issuer {
+		return errors.Wrap(ErrInvalidToken, "issuer mismatched")
+	}
+
+	if opts.Audience != "" && !hasAudience(claims["aud"], opts.Audience) {
+		return errors.Wrap(ErrInvalidToken, "audience mismatched")
+	}
+	return nil
+}
+
+// numericDate return the time of the NumericDate claim, which is the seconds since the epoch
+func numericDate(claims Claims, name string) (time.Time, bool, error) {
+	val, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	num, ok := val.(json.Number)
+	if !ok {
+		return time.Time{}, false, errors.Wrap(ErrInvalidToken, "malformed "+name)
+	}
+
+	sec, err := num.Float64()
+	if err != nil {
+		return time.Time{}, false, errors.Wrap(ErrInvalidToken, "malformed "+name)
+	}
+	return time.Unix(int64(sec), 0), true, nil
+}
+
+// hasAudience check the "aud" claim, which is either a string or an array of strings
+func hasAudience(aud interface{}, audience string) bool {
+	switch val := aud.(type) {
+	case string:
+		return val == audience
+	case []interface{}:
+		for _, item := range val {
+			if item == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadHMACKey load the HMAC secret from the file, the surrounding whitespaces are trimmed
+func LoadHMACKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read hmac key")
+	}
+	return bytes.TrimSpace(data), nil
+}
+
+// LoadPublicKey load the RSA or ECDSA public key from the PEM file, which is a PKIX "PUBLIC KEY",
+// a PKCS #1 "RSA PUBLIC KEY" or a "CERTIFICATE"
+func LoadPublicKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read public key")
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found in " + path)
+	}
+
+	var key crypto.PublicKey
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, errors.New("unsupported PEM block " + block.Type)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "parse public key")
+	}
+
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	}
+	return nil, errors.New("unsupported public key type")
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+)
+
+// signJWT sign the claims as a compact JWT, the key is the HMAC secret or the private key
+func signJWT(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	hash := jwtHashes[alg[2:]]
+	h := hash.New()
+	h.Write([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, hash, h.Sum(nil)); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, h.Sum(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		signature = make([]byte, 2*size)
+		r.FillBytes(signature[:size])
+		s.FillBytes(signature[size:])
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writePublicKey(t *testing.T, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestJWT(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	rsaPub, err := LoadPublicKey(writePublicKey(t, &rsaKey.PublicKey))
+	if err != nil {
+		t.Fatalf("LoadPublicKey(rsa) error = %v", err)
+	}
+	ecPub, err := LoadPublicKey(writePublicKey(t, &ecKey.PublicKey))
+	if err != nil {
+		t.Fatalf("LoadPublicKey(ecdsa) error = %v", err)
+	}
+
+	secretPath := filepath.Join(t.TempDir(), "secret")
+	os.WriteFile(secretPath, []byte("hmac-secret\n"), 0600)
+	secret, err := LoadHMACKey(secretPath)
+	if err != nil {
+		t.Fatalf("LoadHMACKey() error = %v", err)
+	}
+
+	now := time.Unix(1600000000, 0)
+	filter := JWT(JWTOptions{
+		Keys:     map[string]interface{}{"": secret, "rsa": rsaPub, "ec": ecPub},
+		Issuer:   "bytom",
+		Audience: "wallet",
+		Leeway:   time.Minute,
+		Now:      func() time.Time { return now },
+	})
+
+	claims := func(exp time.Time, aud interface{}) map[string]interface{} {
+		return map[string]interface{}{"sub": "alice", "iss": "bytom", "aud": aud, "exp": exp.Unix()}
+	}
+	valid := claims(now.Add(time.Hour), []string{"explorer", "wallet"})
+
+	cases := []struct {
+		token string
+		err   error
+	}{
+		{token: signJWT(t, "HS256", "", secret, valid)},
+		{token: signJWT(t, "RS256", "rsa", rsaKey, valid)},
+		{token: signJWT(t, "ES256", "ec", ecKey, valid)},
+		{token: signJWT(t, "HS512", "", secret, claims(now.Add(-30*time.Second), "wallet"))},
+		{token: signJWT(t, "HS256", "", secret, claims(now.Add(-2*time.Minute), "wallet")), err: ErrTokenExpired},
+		{token: signJWT(t, "HS256", "", secret, claims(now.Add(time.Hour), "explorer")), err: ErrInvalidToken},
+		{token: signJWT(t, "HS256", "", []byte("wrong"), valid), err: ErrInvalidToken},
+		{token: signJWT(t, "RS256", "ec", rsaKey, valid), err: ErrInvalidToken},
+		{token: signJWT(t, "HS256", "rsa", secret, valid), err: ErrInvalidToken},
+		{token: "not.a.token", err: ErrInvalidToken},
+		{err: ErrMissingToken},
+	}
+
+	for i, cs := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if cs.token != "" {
+			r.Header.Set("Authorization", "Bearer "+cs.token)
+		}
+
+		c, err := runFilter(filter, r)
+		if errors.Root(err) != cs.err {
+			t.Errorf("case %d: error = %v, want %v", i, err, cs.err)
+			continue
+		}
+
+		if cs.err == nil {
+			if claims, ok := GetClaims(c); !ok || claims.Subject() != "alice" {
+				t.Errorf("case %d: claims = %v, want the subject alice", i, claims)
+			}
+		}
+	}
+}
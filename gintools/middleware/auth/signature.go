@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+// the headers of the signed request
+const (
+	HeaderAccessKey = "X-Access-Key"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderNonce     = "X-Nonce"
+	HeaderSignature = "X-Signature"
+)
+
+const (
+	defaultMaxSkew     = 5 * time.Minute
+	defaultMaxBodySize = 1 << 20
+)
+
+var (
+	// ErrMissingSignature is returned when any of the signing headers is absent
+	ErrMissingSignature = errors.New("missing request signature")
+	// ErrInvalidSignature is returned when the access key is unknown or the signature mismatched
+	ErrInvalidSignature = errors.New("invalid request signature")
+	// ErrRequestExpired is returned when the timestamp of the request is out of the allowed skew
+	ErrRequestExpired = errors.New("request timestamp expired")
+	// ErrReplayedNonce is returned when the nonce has been used in the allowed skew
+	ErrReplayedNonce = errors.New("request nonce replayed")
+	// ErrBodyTooLarge is returned when the request body exceeds the max body size, which is responded with HTTP 413
+	ErrBodyTooLarge = handler.ErrBodyTooLarge
+)
+
+// NonceStore remember the used nonces to reject the replayed requests
+type NonceStore interface {
+	// Add add the nonce which expires after ttl, and return false if it exists
+	Add(nonce string, ttl time.Duration) (bool, error)
+}
+
+// MemoryNonceStore is an in-memory NonceStore for single instance services
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	now    func() time.Time
+	// sweepAt is the time to remove the expired nonces next
+	sweepAt time.Time
+}
+
+// NewMemoryNonceStore return an empty in-memory nonce store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time), now: time.Now}
+}
+
+// Add add the nonce, the expired nonces are removed at most once per ttl
+func (s *MemoryNonceStore) Add(nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !now.Before(s.sweepAt) {
+		for n, expiry := range s.nonces {
+			if !now.Before(expiry) {
+				delete(s.nonces, n)
+			}
+		}
+		s.sweepAt = now.Add(ttl)
+	}
+
+	if expiry, ok := s.nonces[nonce]; ok && now.Before(expiry) {
+		return false, nil
+	}
+
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// SignatureOptions is the options of the HMAC request signing authentication
+type SignatureOptions struct {
+	// Secrets map the access keys to their secrets
+	Secrets map[string][]byte
+	// MaxSkew is the max difference between the request timestamp and the server time, default is 5 minutes
+	MaxSkew time.Duration
+	// Nonces remember the used nonces, a MemoryNonceStore is used if it is nil
+	Nonces NonceStore
+	// Now return the server time, time.Now is used if it is nil
+	Now func() time.Time
+	// MaxBodySize is the max bytes of the request body to be signed, default is 1MB
+	MaxBodySize int64
+}
+
+// Signature return a front filter which authenticate the request signed by HMAC-SHA256. The client sends the
+// access key, the unix timestamp in seconds, a random nonce and the hex signature of StringToSign in the headers.
+// The access key is set into the gin context as the client name.
+func Signature(opts SignatureOptions) handler.FrontFilter {
+	if opts.MaxSkew == 0 {
+		opts.MaxSkew = defaultMaxSkew
+	}
+	if opts.Nonces == nil {
+		opts.Nonces = NewMemoryNonceStore()
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultMaxBodySize
+	}
+
+	return func(c *gin.Context) error {
+		accessKey, timestamp := c.GetHeader(HeaderAccessKey), c.GetHeader(HeaderTimestamp)
+		nonce, signature := c.GetHeader(HeaderNonce), c.GetHeader(HeaderSignature)
+		if accessKey == "" || timestamp == "" || nonce == "" || signature == "" {
+			return ErrMissingSignature
+		}
+
+		sec, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return errors.Wrap(ErrInvalidSignature, "parse timestamp")
+		}
+
+		if skew := opts.Now().Sub(time.Unix(sec, 0)); skew > opts.MaxSkew || skew < -opts.MaxSkew {
+			return ErrRequestExpired
+		}
+
+		body, err := readBody(c, opts.MaxBodySize)
+		if err == ErrBodyTooLarge {
+			return err
+		} else if err != nil {
+			return errors.Wrap(err, "read body")
+		}
+
+		secret, ok := opts.Secrets[accessKey]
+		expected := Sign(secret, StringToSign(c.Request.Method, c.Request.URL.Path, c.Request.URL.RawQuery, timestamp, nonce, body))
+		if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) || !ok {
+			return ErrInvalidSignature
+		}
+
+		// a nonce only needs to be remembered while its timestamp is acceptable
+		added, err := opts.Nonces.Add(accessKey+":"+nonce, 2*opts.MaxSkew)
+		if err != nil {
+			return errors.Wrap(err, "add nonce")
+		}
+		if !added {
+			return ErrReplayedNonce
+		}
+
+		c.Set(ContextKeyClient, accessKey)
+		return nil
+	}
+}
+
+// StringToSign return the canonical string of the request to be signed, which is the method, path, raw query,
+// timestamp, nonce and hex SHA-256 of the body joined by newlines
+func StringToSign(method, path, rawQuery, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{strings.ToUpper(method), path, rawQuery, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")
+}
+
+// Sign return the hex HMAC-SHA256 signature of the string
+func Sign(secret []byte, stringToSign string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(stringToSign))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// readBody read the request body of at most maxSize bytes, and restore it for the binding afterwards
+func readBody(c *gin.Context, maxSize int64) ([]byte, error) {
+	if c.Request.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, ErrBodyTooLarge
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
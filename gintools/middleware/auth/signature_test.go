@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+func signedRequest(secret []byte, timestamp int64, nonce, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/v1/transactions?chain=btm", strings.NewReader(body))
+	ts := strconv.FormatInt(timestamp, 10)
+	r.Header.Set(HeaderAccessKey, "ak")
+	r.Header.Set(HeaderTimestamp, ts)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, Sign(secret, StringToSign(r.Method, "/v1/transactions", "chain=btm", ts, nonce, []byte(body))))
+	return r
+}
+
+func TestSignature(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	secret := []byte("secret")
+	filter := Signature(SignatureOptions{Secrets: map[string][]byte{"ak": secret}, Now: func() time.Time { return now }})
+
+	c, err := runFilter(filter, signedRequest(secret, now.Unix(), "n1", `{"tx":"raw"}`))
+	if err != nil {
+		t.Fatalf("Signature() error = %v", err)
+	}
+	if body, _ := io.ReadAll(c.Request.Body); string(body) != `{"tx":"raw"}` {
+		t.Errorf("body = %q, want the body restored for binding", body)
+	}
+	if c.GetString(ContextKeyClient) != "ak" {
+		t.Errorf("client = %q, want ak", c.GetString(ContextKeyClient))
+	}
+
+	tampered := signedRequest(secret, now.Unix(), "n2", `{"tx":"raw"}`)
+	tampered.Body = io.NopCloser(strings.NewReader(`{"tx":"forged"}`))
+	missing := signedRequest(secret, now.Unix(), "n4", "")
+	missing.Header.Del(HeaderNonce)
+
+	cases := []struct {
+		r   *http.Request
+		err error
+	}{
+		{r: signedRequest(secret, now.Unix(), "n1", `{"tx":"raw"}`), err: ErrReplayedNonce},
+		{r: tampered, err: ErrInvalidSignature},
+		{r: signedRequest([]byte("wrong"), now.Unix(), "n3", ""), err: ErrInvalidSignature},
+		{r: signedRequest(secret, now.Add(-6*time.Minute).Unix(), "n5", ""), err: ErrRequestExpired},
+		{r: missing, err: ErrMissingSignature},
+	}
+
+	for i, cs := range cases {
+		if _, err := runFilter(filter, cs.r); errors.Root(err) != cs.err {
+			t.Errorf("case %d: error = %v, want %v", i, err, cs.err)
+		}
+	}
+}
+
+func TestMemoryNonceStore(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	store := NewMemoryNonceStore()
+	store.now = func() time.Time { return now }
+
+	if added, _ := store.Add("n", time.Minute); !added {
+		t.Fatal("Add() = false, want the new nonce added")
+	}
+	if added, _ := store.Add("n", time.Minute); added {
+		t.Error("Add() = true, want the duplicated nonce rejected")
+	}
+
+	now = now.Add(time.Minute)
+	if added, _ := store.Add("n", time.Minute); !added {
+		t.Error("Add() = false, want the expired nonce added again")
+	}
+
+	if added, _ := store.Add("short", time.Second); !added {
+		t.Fatal("Add() = false, want the new nonce added")
+	}
+	now = now.Add(time.Second)
+	if added, _ := store.Add("short", time.Second); !added {
+		t.Error("Add() = false, want the expired nonce added again before the sweep")
+	}
+}
+
+func TestSignatureBodySize(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	secret := []byte("secret")
+	filter := Signature(SignatureOptions{Secrets: map[string][]byte{"ak": secret}, Now: func() time.Time { return now }, MaxBodySize: 4})
+
+	if _, err := runFilter(filter, signedRequest(secret, now.Unix(), "n1", `"ok"`)); err != nil {
+		t.Errorf("Signature() error = %v, want the body of the max size accepted", err)
+	}
+	if _, err := runFilter(filter, signedRequest(secret, now.Unix(), "n2", `"big"`)); err != ErrBodyTooLarge {
+		t.Errorf("Signature() error = %v, want %v", err, ErrBodyTooLarge)
+	}
+
+	h := handler.NewHandler(nil, []handler.FrontFilter{filter}, nil).SetResponseAdaptor(&handler.StatusResponse{})
+	engine := gin.New()
+	engine.POST("/v1/transactions", h.HandleMiddleware(func(c *gin.Context) (string, error) { return "ok", nil }))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, signedRequest(secret, now.Unix(), "n3", `"big"`))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large body = %d %s, want 413", w.Code, w.Body.String())
+	}
+}
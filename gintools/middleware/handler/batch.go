@@ -0,0 +1,536 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// ErrInvalidBatch is responded when the batch request is malformed, empty or has too many calls
+	ErrInvalidBatch = errors.New("invalid batch request")
+	// ErrBatchMethodNotFound is responded for the call whose method is not the name of a registered route
+	ErrBatchMethodNotFound = errors.New("batch method not found")
+	// ErrInvalidBatchParams is responded for the call whose params are not an object or miss a path param
+	ErrInvalidBatchParams = errors.New("invalid batch params")
+)
+
+// the error codes of JSON-RPC 2.0
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// BatchMode is the protocol of the batch endpoint
+type BatchMode int
+
+const (
+	// BatchPlain accept a JSON array of BatchCall, and respond a JSON array of BatchResult
+	BatchPlain BatchMode = iota
+	// BatchJSONRPC accept a JSON-RPC 2.0 request or batch, whose results are the response envelopes of the routes
+	BatchJSONRPC
+)
+
+// BatchOptions is the options of the batch endpoint
+type BatchOptions struct {
+	Mode BatchMode
+	// MaxCalls is the max number of the calls of a batch request
+	MaxCalls int
+	// Concurrency is the max number of the calls processed at the same time
+	Concurrency int
+	// MaxBodySize is the max bytes of the batch request body
+	MaxBodySize int64
+}
+
+// DefaultBatchOptions is the batch options whose zero fields are used instead
+var DefaultBatchOptions = BatchOptions{
+	Mode:        BatchPlain,
+	MaxCalls:    100,
+	Concurrency: 8,
+	MaxBodySize: 1 << 20,
+}
+
+// BatchCall is a call of the plain batch request, Method is the name of a registered route.
+// The params fill the path params of the route, the others are sent as the query of GET, HEAD
+// and DELETE routes, and the whole params are sent as the JSON body of the other routes.
+type BatchCall struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// BatchResult is the result of a call of the plain batch request, Response is the envelope responded by the route
+type BatchResult struct {
+	ID       json.RawMessage `json:"id,omitempty"`
+	Status   int             `json:"status"`
+	Response json.RawMessage `json:"response"`
+}
+
+// RPCRequest is a request of JSON-RPC 2.0, a request without id is a notification
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// RPCResponse is a response of JSON-RPC 2.0, Result is the envelope responded by the route
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// RPCError is the error object of JSON-RPC 2.0, which is returned only if the call is not dispatched to a route
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// batchOutcome is the outcome of a call, err is set if the call is not dispatched to a route
+type batchOutcome struct {
+	status int
+	body   json.RawMessage
+	err    error
+}
+
+// Batch return a gin-compatible processing function of the batch endpoint, which dispatches each call to the
+// route of the route registry by its name, and processes the calls concurrently. Every call goes through the
+// middleware of the gin router group, the filters, binding and response adaptor of its route with a copy of
+// the batch request, whose headers and context keys are inherited. Streaming routes can not be called in batch.
+// The routes protected by auth.Signature can not be called in batch either, because the inherited signature
+// headers sign the batch request instead of the call.
+func (h *Handler) Batch(opts BatchOptions) gin.HandlerFunc {
+	if opts.MaxCalls <= 0 {
+		opts.MaxCalls = DefaultBatchOptions.MaxCalls
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultBatchOptions.Concurrency
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultBatchOptions.MaxBodySize
+	}
+
+	if opts.Mode == BatchJSONRPC {
+		return func(c *gin.Context) {
+			h.serveJSONRPC(c, opts)
+		}
+	}
+	return func(c *gin.Context) {
+		h.serveBatch(c, opts)
+	}
+}
+
+// serveBatch process the plain batch request
+func (h *Handler) serveBatch(c *gin.Context, opts BatchOptions) {
+	body, err := readBody(c, opts.MaxBodySize)
+	if err != nil {
+		h.respondBatchError(c, err)
+		return
+	}
+
+	var calls []BatchCall
+	if err := json.Unmarshal(body, &calls); err != nil {
+		h.respondBatchError(c, errors.Wrap(ErrInvalidBatch, err.Error()))
+		return
+	}
+	if err := checkBatchSize(len(calls), opts); err != nil {
+		h.respondBatchError(c, err)
+		return
+	}
+
+	outcomes := h.runBatch(c, len(calls), opts, func(cp *gin.Context, i int) batchOutcome {
+		return h.dispatch(cp, calls[i].Method, calls[i].Params)
+	})
+
+	results := make([]BatchResult, len(calls))
+	for i, outcome := range outcomes {
+		if outcome.err != nil {
+			outcome = h.renderBatchError(c, outcome.err)
+		}
+		results[i] = BatchResult{ID: calls[i].ID, Status: outcome.status, Response: outcome.body}
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// serveJSONRPC process the JSON-RPC 2.0 request, the notifications are processed without response
+func (h *Handler) serveJSONRPC(c *gin.Context, opts BatchOptions) {
+	body, err := readBody(c, opts.MaxBodySize)
+	if err == ErrBodyTooLarge {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcInvalidRequest, err))
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcParseError, err))
+		return
+	}
+
+	if !json.Valid(body) {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcParseError, errors.New("malformed JSON")))
+		return
+	}
+
+	single := body[0] != '['
+	var entries []json.RawMessage
+	if single {
+		entries = []json.RawMessage{body}
+	} else if err := json.Unmarshal(body, &entries); err != nil {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcInvalidRequest, err))
+		return
+	}
+	if err := checkBatchSize(len(entries), opts); err != nil {
+		c.JSON(http.StatusOK, rpcErrorResponse(nil, rpcInvalidRequest, err))
+		return
+	}
+
+	requests := make([]RPCRequest, len(entries))
+	notifications := make([]bool, len(entries))
+	invalid := make([]error, len(entries))
+	for i, entry := range entries {
+		requests[i], notifications[i], invalid[i] = parseRPCRequest(entry)
+	}
+
+	outcomes := h.runBatch(c, len(entries), opts, func(cp *gin.Context, i int) batchOutcome {
+		if invalid[i] != nil {
+			return batchOutcome{err: invalid[i]}
+		}
+		return h.dispatch(cp, requests[i].Method, requests[i].Params)
+	})
+
+	var responses []RPCResponse
+	for i, outcome := range outcomes {
+		if notifications[i] {
+			continue
+		}
+		if outcome.err != nil {
+			responses = append(responses, rpcErrorResponse(requests[i].ID, rpcErrorCode(outcome.err), outcome.err))
+			continue
+		}
+		responses = append(responses, RPCResponse{JSONRPC: "2.0", Result: outcome.body, ID: requests[i].ID})
+	}
+
+	switch {
+	case len(responses) == 0:
+		c.Status(http.StatusNoContent)
+	case single:
+		c.JSON(http.StatusOK, responses[0])
+	default:
+		c.JSON(http.StatusOK, responses)
+	}
+}
+
+// parseRPCRequest parse an entry of the JSON-RPC request, and report whether it is a notification
+func parseRPCRequest(entry json.RawMessage) (RPCRequest, bool, error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(entry, &members); err != nil {
+		return RPCRequest{}, false, errors.Wrap(ErrInvalidBatch, err.Error())
+	}
+
+	var req RPCRequest
+	if err := json.Unmarshal(entry, &req); err != nil {
+		return RPCRequest{}, false, errors.Wrap(ErrInvalidBatch, err.Error())
+	}
+
+	id, hasID := members["id"]
+	req.ID = id
+	if req.JSONRPC != "2.0" {
+		return req, false, errors.Wrap(ErrInvalidBatch, `jsonrpc must be "2.0"`)
+	}
+	if req.Method == "" {
+		return req, false, errors.Wrap(ErrInvalidBatch, "method is required")
+	}
+	return req, !hasID, nil
+}
+
+func rpcErrorResponse(id json.RawMessage, code int, err error) RPCResponse {
+	rpcErr := &RPCError{Code: code}
+	switch code {
+	case rpcParseError:
+		rpcErr.Message = "Parse error"
+	case rpcInvalidRequest:
+		rpcErr.Message = "Invalid Request"
+	case rpcMethodNotFound:
+		rpcErr.Message = "Method not found"
+	case rpcInvalidParams:
+		rpcErr.Message = "Invalid params"
+	default:
+		rpcErr.Message = "Internal error"
+	}
+	if _, isPanic := err.(*PanicError); !isPanic {
+		rpcErr.Data = err.Error()
+	}
+	return RPCResponse{JSONRPC: "2.0", Error: rpcErr, ID: id}
+}
+
+// rpcErrorCode return the JSON-RPC error code of the error which fails to dispatch the call
+func rpcErrorCode(err error) int {
+	switch errors.Root(err) {
+	case ErrInvalidBatch:
+		return rpcInvalidRequest
+	case ErrBatchMethodNotFound:
+		return rpcMethodNotFound
+	case ErrInvalidBatchParams:
+		return rpcInvalidParams
+	default:
+		return rpcInternalError
+	}
+}
+
+func checkBatchSize(n int, opts BatchOptions) error {
+	if n == 0 {
+		return errors.Wrap(ErrInvalidBatch, "no call")
+	}
+	if n > opts.MaxCalls {
+		return errors.Wrap(ErrInvalidBatch, "too many calls")
+	}
+	return nil
+}
+
+// readBody read the request body of at most maxSize bytes, and return ErrBodyTooLarge if it exceeds
+func readBody(c *gin.Context, maxSize int64) ([]byte, error) {
+	var buf bytes.Buffer
+	if c.Request.Body != nil {
+		if _, err := buf.ReadFrom(io.LimitReader(c.Request.Body, maxSize+1)); err != nil {
+			return nil, err
+		}
+	}
+	if int64(buf.Len()) > maxSize {
+		return nil, ErrBodyTooLarge
+	}
+	return bytes.TrimSpace(buf.Bytes()), nil
+}
+
+// runBatch run the calls with at most opts.Concurrency goroutines, and return the outcomes in the order of the calls.
+// A panic escaping from the route is recovered as the outcome of its call.
+func (h *Handler) runBatch(c *gin.Context, n int, opts BatchOptions, call func(c *gin.Context, i int) batchOutcome) []batchOutcome {
+	outcomes := make([]batchOutcome, n)
+	sem := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer func() {
+				if r := recover(); r != nil {
+					outcomes[i] = batchOutcome{err: newPanicError(r)}
+				}
+				<-sem
+				wg.Done()
+			}()
+
+			outcomes[i] = call(c, i)
+		}(i)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// dispatch call the handler chain of the route of the method with the params, and capture the response of the route
+func (h *Handler) dispatch(c *gin.Context, method string, params json.RawMessage) batchOutcome {
+	route, ok := h.routes.Lookup(method)
+	if !ok || route.Stream {
+		return batchOutcome{err: errors.Wrap(ErrBatchMethodNotFound, method)}
+	}
+
+	req, err := newBatchRequest(c, route, params)
+	if err != nil {
+		return batchOutcome{err: err}
+	}
+
+	capture := &captureWriter{ResponseWriter: c.Writer, header: http.Header{}, status: http.StatusOK}
+	route.engine.serve(route, capture, req.WithContext(context.WithValue(req.Context(), batchKeysKey{}, c.Keys)))
+	return batchOutcome{status: capture.status, body: capturedJSON(capture)}
+}
+
+// batchKeysKey is the key of the request context which carries the context keys of the batch request
+type batchKeysKey struct{}
+
+// inheritKeys set the context keys of the batch request into the gin context of the call, the call is
+// filtered by its route even if the batch request has been filtered
+func inheritKeys(c *gin.Context) {
+	keys, _ := c.Request.Context().Value(batchKeysKey{}).(map[string]interface{})
+	for key, val := range keys {
+		if key != frontFilteredLabel {
+			c.Set(key, val)
+		}
+	}
+}
+
+// routeEngine serve the handler chain of a route with a gin engine of its own, which is built at the first call
+type routeEngine struct {
+	once   sync.Once
+	engine *gin.Engine
+}
+
+func (e *routeEngine) serve(route RouteInfo, w http.ResponseWriter, req *http.Request) {
+	e.once.Do(func() {
+		handlers := route.Handlers
+		if len(handlers) == 0 {
+			handlers = gin.HandlersChain{route.Handle}
+		}
+
+		e.engine = gin.New()
+		// match the escaped path, so that the path params may contain slashes
+		e.engine.UseRawPath = true
+		e.engine.Handle(route.Method, route.Path, append(gin.HandlersChain{inheritKeys}, handlers...)...)
+	})
+	e.engine.ServeHTTP(w, req)
+}
+
+// newBatchRequest build the request of the route from the params, which inherits the headers and the context of the batch request
+func newBatchRequest(c *gin.Context, route RouteInfo, raw json.RawMessage) (*http.Request, error) {
+	params := map[string]interface{}{}
+	if len(raw) > 0 && string(raw) != "null" {
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&params); err != nil {
+			return nil, errors.Wrap(ErrInvalidBatchParams, "params must be an object")
+		}
+	}
+
+	segments := strings.Split(route.Path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+
+		key := segment[1:]
+		value, ok := params[key]
+		if !ok || value == nil {
+			return nil, errors.Wrap(ErrInvalidBatchParams, "missing path param "+key)
+		}
+		values := formatParam(value)
+		if len(values) != 1 {
+			return nil, errors.Wrap(ErrInvalidBatchParams, "path param "+key+" must be a scalar")
+		}
+
+		segments[i] = url.PathEscape(values[0])
+		if segment[0] == '*' {
+			// the value of the catch-all param starts with a slash as gin does
+			segments[i] = strings.TrimPrefix(values[0], "/")
+		}
+		delete(params, key)
+	}
+
+	target := strings.Join(segments, "/")
+	var body []byte
+	switch route.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		query := url.Values{}
+		for key, value := range params {
+			for _, v := range formatParam(value) {
+				query.Add(key, v)
+			}
+		}
+		if len(query) > 0 {
+			target += "?" + query.Encode()
+		}
+	default:
+		body = raw
+	}
+
+	req, err := http.NewRequestWithContext(c.Request.Context(), route.Method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidBatchParams, err.Error())
+	}
+
+	req.Header = c.Request.Header.Clone()
+	req.Header.Del("Content-Length")
+	req.Header.Set("Accept", gin.MIMEJSON)
+	if body != nil {
+		req.Header.Set("Content-Type", gin.MIMEJSON)
+	} else {
+		req.Header.Del("Content-Type")
+		req.Body = http.NoBody
+	}
+	req.Host, req.RemoteAddr, req.RequestURI = c.Request.Host, c.Request.RemoteAddr, target
+	return req, nil
+}
+
+// formatParam format the param as the values of the query, the objects are formatted as JSON
+func formatParam(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case json.Number:
+		return []string{v.String()}
+	case bool:
+		if v {
+			return []string{"true"}
+		}
+		return []string{"false"}
+	case []interface{}:
+		var values []string
+		for _, item := range v {
+			values = append(values, formatParam(item)...)
+		}
+		return values
+	default:
+		b, _ := json.Marshal(v)
+		return []string{string(b)}
+	}
+}
+
+// renderBatchError render the error which fails to dispatch the call through the response adaptor
+func (h *Handler) renderBatchError(c *gin.Context, err error) batchOutcome {
+	capture := captureResponse(c, func(cp *gin.Context) {
+		cp.Errors = nil
+		if panicErr, ok := err.(*PanicError); ok {
+			h.respondErrorSpec(cp, err, h.panicSpec(panicErr))
+			return
+		}
+		h.respondErrorSpec(cp, err, h.batchErrorSpec(err))
+	})
+	return batchOutcome{status: capture.status, body: capturedJSON(capture)}
+}
+
+// respondBatchError respond the error of the whole batch request through the response adaptor
+func (h *Handler) respondBatchError(c *gin.Context, err error) {
+	h.respondErrorSpec(c, err, h.batchErrorSpec(err))
+}
+
+// batchErrorSpec resolve the ErrorSpec of the batch error, which defaults to HTTP 404 for ErrBatchMethodNotFound,
+// HTTP 413 for ErrBodyTooLarge and HTTP 400 for the others, register the batch errors to respond them with the error codes
+func (h *Handler) batchErrorSpec(err error) ErrorSpec {
+	spec, _ := h.errorRegistry.Resolve(err)
+	if spec.HTTPStatus == 0 {
+		switch errors.Root(err) {
+		case ErrBatchMethodNotFound:
+			spec.HTTPStatus = http.StatusNotFound
+		case ErrBodyTooLarge:
+			spec.HTTPStatus = http.StatusRequestEntityTooLarge
+		default:
+			spec.HTTPStatus = http.StatusBadRequest
+		}
+	}
+	return spec
+}
+
+// capturedJSON return the captured response body, which is quoted as a JSON string if it is not JSON
+func capturedJSON(capture *captureWriter) json.RawMessage {
+	body := bytes.TrimSpace(capture.body.Bytes())
+	if len(body) == 0 {
+		return json.RawMessage("null")
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body)
+	}
+
+	b, _ := json.Marshal(string(body))
+	return json.RawMessage(b)
+}
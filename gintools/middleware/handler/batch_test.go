@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type batchOrderReq struct {
+	ID      uint64 `uri:"id" binding:"required"`
+	Verbose bool   `query:"verbose"`
+}
+
+type batchCreateReq struct {
+	Amount uint64 `json:"amount" binding:"required"`
+}
+
+func newBatchEngine(opts BatchOptions) (*gin.Engine, *int32) {
+	errNotFound := errors.New("order not found")
+	errUnauthorized := errors.New("unauthorized")
+	auth := func(c *gin.Context) error {
+		if c.GetHeader("X-Token") != "secret" {
+			return errUnauthorized
+		}
+		return nil
+	}
+
+	var inFlight, maxInFlight int32
+	h := NewHandler(map[error]int{errNotFound: 40400, errUnauthorized: 40100, ErrBatchMethodNotFound: 40401}, []FrontFilter{auth}, nil).
+		SetValidationErrCode(40000).
+		SetPanicErrCode(50000)
+	engine := gin.New()
+	g := h.Group(engine, "/api")
+	g.GET("/orders/:id", func(c *gin.Context, req *batchOrderReq) (map[string]interface{}, error) {
+		if req.ID == 404 {
+			return nil, errNotFound
+		}
+		return map[string]interface{}{"id": req.ID, "verbose": req.Verbose}, nil
+	}, WithName("getOrder"))
+	g.POST("/orders", func(c *gin.Context, req *batchCreateReq) (uint64, error) {
+		return req.Amount, nil
+	}, WithName("createOrder"))
+	g.GET("/slow", func(c *gin.Context) (string, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			max := atomic.LoadInt32(&maxInFlight)
+			if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		return "done", nil
+	}, WithName("slow"))
+	g.GET("/panic", func(c *gin.Context) (string, error) { panic("boom") }, WithName("panic"))
+	HandleStream(g, http.MethodGet, "/feed", func(c *gin.Context, req *Empty) (<-chan int, error) { return nil, nil }, WithName("feed"))
+
+	engine.POST("/batch", h.Batch(opts))
+	return engine, &maxInFlight
+}
+
+func postBatch(engine *gin.Engine, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Token", "secret")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	return w
+}
+
+func TestBatch(t *testing.T) {
+	engine, maxInFlight := newBatchEngine(BatchOptions{Concurrency: 2})
+
+	w := postBatch(engine, `[
+		{"id": 1, "method": "getOrder", "params": {"id": 7, "verbose": true}},
+		{"id": 2, "method": "getOrder", "params": {"id": 404}},
+		{"id": 3, "method": "createOrder", "params": {"amount": 12}},
+		{"id": 4, "method": "createOrder", "params": {}},
+		{"id": 5, "method": "getOrder"},
+		{"id": 6, "method": "unknown"},
+		{"id": 7, "method": "feed"},
+		{"id": 8, "method": "panic"}
+	]`)
+	var results []json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("batch = %d %s, %v", w.Code, w.Body.String(), err)
+	}
+
+	want := []string{
+		`{"id":1,"status":200,"response":{"code":200,"msg":"","data":{"id":7,"verbose":true}}}`,
+		`{"id":2,"status":200,"response":{"code":40400,"msg":"order not found"}}`,
+		`{"id":3,"status":200,"response":{"code":200,"msg":"","data":12}}`,
+		`{"id":4,"status":200,"response":{"code":40000,`,
+		`{"id":5,"status":200,"response":{"code":300,"msg":"request error"}}`,
+		`{"id":6,"status":200,"response":{"code":40401,"msg":"batch method not found"}}`,
+		`{"id":7,"status":200,"response":{"code":40401,"msg":"batch method not found"}}`,
+		`{"id":8,"status":200,"response":{"code":50000,"msg":"internal server error"}}`,
+	}
+	for i, result := range results {
+		if i >= len(want) || !strings.HasPrefix(string(result), want[i]) {
+			t.Errorf("result %d = %s, want %s", i, result, want[i])
+		}
+	}
+	if len(results) != len(want) {
+		t.Errorf("results = %d, want %d", len(results), len(want))
+	}
+
+	w = postBatch(engine, `[{"method":"slow"},{"method":"slow"},{"method":"slow"},{"method":"slow"},{"method":"slow"}]`)
+	if !strings.Contains(w.Body.String(), `"data":"done"`) || *maxInFlight != 2 {
+		t.Errorf("concurrent calls = %d, want bounded by 2: %s", *maxInFlight, w.Body.String())
+	}
+}
+
+func TestBatchFilters(t *testing.T) {
+	engine, _ := newBatchEngine(BatchOptions{})
+
+	r := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"method":"getOrder","params":{"id":7}}]`))
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), `"response":{"code":40100,"msg":"unauthorized"}`) {
+		t.Errorf("batch without token = %s, want the front filter of the route rejects the call", w.Body.String())
+	}
+
+	for _, body := range []string{`{}`, `[]`, `[` + strings.Repeat(`{"method":"slow"},`, 100) + `{"method":"slow"}]`} {
+		w := postBatch(engine, body)
+		if !strings.Contains(w.Body.String(), `"code":300`) || strings.Contains(w.Body.String(), `"status"`) {
+			t.Errorf("batch %.20s = %s, want the error of the whole batch", body, w.Body.String())
+		}
+	}
+}
+
+func TestBatchJSONRPC(t *testing.T) {
+	engine, _ := newBatchEngine(BatchOptions{Mode: BatchJSONRPC})
+
+	cases := []struct {
+		body string
+		want string
+	}{
+		{
+			body: `{"jsonrpc":"2.0","method":"getOrder","params":{"id":7},"id":"a"}`,
+			want: `{"jsonrpc":"2.0","result":{"code":200,"msg":"","data":{"id":7,"verbose":false}},"id":"a"}`,
+		},
+		{
+			body: `[
+				{"jsonrpc":"2.0","method":"getOrder","params":{"id":404},"id":1},
+				{"jsonrpc":"2.0","method":"createOrder","params":{"amount":3}},
+				{"jsonrpc":"2.0","method":"unknown","id":2},
+				{"jsonrpc":"2.0","method":"getOrder","params":[7],"id":3},
+				{"jsonrpc":"1.0","method":"getOrder","id":4}
+			]`,
+			want: `[{"jsonrpc":"2.0","result":{"code":40400,"msg":"order not found"},"id":1},` +
+				`{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found","data":"unknown: batch method not found"},"id":2},` +
+				`{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"params must be an object: invalid batch params"},"id":3},` +
+				`{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"jsonrpc must be \"2.0\": invalid batch request"},"id":4}]`,
+		},
+		{
+			body: `{"jsonrpc":"2.0","method":"getOrder"`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error","data":"malformed JSON"},"id":null}`,
+		},
+		{
+			body: `[]`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"no call: invalid batch request"},"id":null}`,
+		},
+	}
+	for _, c := range cases {
+		w := postBatch(engine, c.body)
+		if got := strings.TrimSpace(w.Body.String()); got != c.want {
+			t.Errorf("JSON-RPC %.30s =\n%s\nwant\n%s", c.body, got, c.want)
+		}
+	}
+
+	w := postBatch(engine, `[{"jsonrpc":"2.0","method":"createOrder","params":{"amount":3}}]`)
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("notifications = %d %s, want no content", w.Code, w.Body.String())
+	}
+}
+
+func TestBatchGroupMiddleware(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetResponseAdaptor(&StatusResponse{})
+	engine := gin.New()
+	admin := engine.Group("/admin", func(c *gin.Context) {
+		if c.GetHeader("X-Admin") != "yes" {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Set("admin", true)
+	})
+	h.Group(admin, "/").GET("/secret", func(c *gin.Context) (bool, error) {
+		return c.GetBool("admin"), nil
+	}, WithName("secret"))
+	engine.POST("/batch", h.Batch(BatchOptions{MaxBodySize: 64}))
+
+	w := postBatch(engine, `[{"method":"secret"}]`)
+	if want := `[{"status":401,"response":null}]`; strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("batch = %s, want %s rejected by the middleware of the group", w.Body.String(), want)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(`[{"method":"secret"}]`))
+	r.Header.Set("X-Admin", "yes")
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if !strings.Contains(w.Body.String(), `"status":200,"response":{"code":200,"msg":"","data":true}`) {
+		t.Errorf("batch = %s, want the call through the middleware of the group", w.Body.String())
+	}
+
+	w = postBatch(engine, `[`+strings.Repeat(`{"method":"secret"},`, 5)+`{"method":"secret"}]`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large batch = %d %s, want 413", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,356 @@
+package handler
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// the sources which the request argument can be bound from
+const (
+	SourceJSON   = "json"
+	SourceForm   = "form"
+	SourceQuery  = "query"
+	SourceHeader = "header"
+	SourceURI    = "uri"
+)
+
+// fieldSources is ordered by precedence, the value from a later source overrides the earlier one
+var fieldSources = []string{SourceForm, SourceQuery, SourceHeader, SourceURI}
+
+const defaultMultipartMemory = 32 << 20
+
+// errUnsupportedContentType is the binding error of the request body which is neither JSON nor a form
+var errUnsupportedContentType = errors.New("unsupported content type")
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// BindError represent a failure of binding one field of the request argument
+type BindError struct {
+	Source string
+	Field  string
+	Key    string
+	Err    error
+}
+
+// Error satisfies the error interface
+func (e *BindError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("bind %s: %v", e.Source, e.Err)
+	}
+	return fmt.Sprintf("bind field %s from %s %q: %v", e.Field, e.Source, e.Key, e.Err)
+}
+
+// Unwrap return the underlying error
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// bindRequest bind the request argument from the sources specified by the struct tags, then validate it.
+// The sources are applied in the following order, and a later one overrides the earlier:
+//
+//	json:   the request body, unless the content type is form-urlencoded or multipart,
+//	        a body of other content type than JSON is rejected
+//	form:   the query string, the url-encoded or multipart form, and the multipart files
+//	query:  the query string only
+//	header: the request headers
+//	uri:    the path params of the route
+//
+// A field tagged with `name,default=value` takes the default value when it is absent in the source
+// and is still zero after the former sources are applied.
+func bindRequest(context *gin.Context, obj interface{}) error {
+	if err := bindBody(context, obj); err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(obj)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		return validateReqArg(obj)
+	}
+
+	if err := bindFields(context, value.Elem()); err != nil {
+		return err
+	}
+
+	return validateReqArg(obj)
+}
+
+func validateReqArg(obj interface{}) error {
+	if binding.Validator == nil {
+		return nil
+	}
+	return binding.Validator.ValidateStruct(obj)
+}
+
+func bindBody(context *gin.Context, obj interface{}) error {
+	req := context.Request
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	switch contentType := context.ContentType(); {
+	case contentType == binding.MIMEPOSTForm || contentType == binding.MIMEMultipartPOSTForm:
+		return nil
+	case !isJSONContentType(contentType):
+		return &BindError{Source: SourceJSON, Err: fmt.Errorf("%w %q", errUnsupportedContentType, contentType)}
+	}
+
+	if err := json.NewDecoder(req.Body).Decode(obj); err != nil && err != io.EOF {
+		bindErr := &BindError{Source: SourceJSON, Err: err}
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+			bindErr.Field, bindErr.Key = typeErr.Field, typeErr.Field
+		}
+		return bindErr
+	}
+	return nil
+}
+
+// isJSONContentType report whether the body of the content type is decoded as JSON, the body without
+// content type is decoded as JSON too
+func isJSONContentType(contentType string) bool {
+	return contentType == "" || contentType == binding.MIMEJSON || strings.HasSuffix(contentType, "+json")
+}
+
+func bindFields(context *gin.Context, value reflect.Value) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field, fieldValue := typ.Field(i), value.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		if field.Anonymous && !HasSourceTag(field) {
+			if fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(fieldValue.Type().Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if fieldValue.Kind() == reflect.Struct {
+				if err := bindFields(context, fieldValue); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		for _, source := range fieldSources {
+			if err := bindField(context, source, field, fieldValue); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FieldSources return the sources of the field tags in the order of precedence, the value from a later source
+// overrides the earlier one
+func FieldSources() []string {
+	return append([]string(nil), fieldSources...)
+}
+
+// HasSourceTag report whether the field is tagged with any of the field sources
+func HasSourceTag(field reflect.StructField) bool {
+	for _, source := range fieldSources {
+		if _, ok := field.Tag.Lookup(source); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func bindField(context *gin.Context, source string, field reflect.StructField, value reflect.Value) error {
+	tag, ok := field.Tag.Lookup(source)
+	if !ok || tag == "-" {
+		return nil
+	}
+
+	key, defaultValue := ParseSourceTag(tag)
+	if key == "" {
+		key = field.Name
+	}
+
+	if source == SourceForm && (value.Type() == fileHeaderType || value.Type() == fileHeaderSliceType) {
+		return bindFileField(context, field, key, value)
+	}
+
+	values, err := lookupSource(context, source, key)
+	if err != nil {
+		return &BindError{Source: source, Field: field.Name, Key: key, Err: err}
+	}
+
+	if len(values) == 0 {
+		// the default never overrides the value bound from the body or a former source
+		if defaultValue == "" || !value.IsZero() {
+			return nil
+		}
+		values = []string{defaultValue}
+	}
+
+	if err := setFieldValues(value, values); err != nil {
+		return &BindError{Source: source, Field: field.Name, Key: key, Err: err}
+	}
+	return nil
+}
+
+// ParseSourceTag parse the tag of a field source, such as "limit,default=10", and return the key and the default value
+func ParseSourceTag(tag string) (key string, defaultValue string) {
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if strings.HasPrefix(opt, "default=") {
+			defaultValue = strings.TrimPrefix(opt, "default=")
+		}
+	}
+	return parts[0], defaultValue
+}
+
+func lookupSource(context *gin.Context, source, key string) ([]string, error) {
+	switch source {
+	case SourceURI:
+		if val, ok := context.Params.Get(key); ok {
+			return []string{val}, nil
+		}
+		return nil, nil
+
+	case SourceHeader:
+		return context.Request.Header[http.CanonicalHeaderKey(key)], nil
+
+	case SourceQuery:
+		return context.Request.URL.Query()[key], nil
+
+	default:
+		if err := parseForm(context.Request); err != nil {
+			return nil, err
+		}
+		return context.Request.Form[key], nil
+	}
+}
+
+func parseForm(req *http.Request) error {
+	if strings.HasPrefix(req.Header.Get("Content-Type"), binding.MIMEMultipartPOSTForm) {
+		if req.MultipartForm != nil {
+			return nil
+		}
+		return req.ParseMultipartForm(defaultMultipartMemory)
+	}
+	return req.ParseForm()
+}
+
+func bindFileField(context *gin.Context, field reflect.StructField, key string, value reflect.Value) error {
+	if err := parseForm(context.Request); err != nil {
+		return &BindError{Source: SourceForm, Field: field.Name, Key: key, Err: err}
+	}
+
+	form := context.Request.MultipartForm
+	if form == nil || len(form.File[key]) == 0 {
+		return nil
+	}
+
+	if value.Type() == fileHeaderType {
+		value.Set(reflect.ValueOf(form.File[key][0]))
+		return nil
+	}
+	value.Set(reflect.ValueOf(form.File[key]))
+	return nil
+}
+
+func setFieldValues(value reflect.Value, values []string) error {
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8 && !reflect.PointerTo(value.Type()).Implements(textUnmarshalerType) {
+		slice := reflect.MakeSlice(value.Type(), len(values), len(values))
+		for i, val := range values {
+			if err := setFieldValue(slice.Index(i), val); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	}
+	return setFieldValue(value, values[0])
+}
+
+func setFieldValue(value reflect.Value, val string) error {
+	if value.Kind() == reflect.Ptr {
+		elem := reflect.New(value.Type().Elem())
+		if err := setFieldValue(elem.Elem(), val); err != nil {
+			return err
+		}
+		value.Set(elem)
+		return nil
+	}
+
+	if value.CanAddr() && value.Addr().Type().Implements(textUnmarshalerType) {
+		return value.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	}
+
+	switch value.Type() {
+	case timeType:
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			return err
+		}
+		value.Set(reflect.ValueOf(t))
+		return nil
+
+	case durationType:
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(val)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(val)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(val, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(val, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(val, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+
+	default:
+		return fmt.Errorf("unsupported field type %s", value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type bindReq struct {
+	ID      uint64   `json:"id" uri:"id"`
+	Status  string   `json:"status" form:"status,default=pending"`
+	Tags    []string `json:"tags" query:"tag"`
+	Token   string   `json:"-" header:"X-Token"`
+	Comment string   `json:"comment"`
+}
+
+func bindWith(t *testing.T, target, body string) (*bindReq, error) {
+	var (
+		req *bindReq
+		err error
+	)
+	engine := gin.New()
+	engine.POST("/orders/:id", func(c *gin.Context) {
+		req = new(bindReq)
+		err = bindRequest(c, req)
+	})
+
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Token", "secret")
+	engine.ServeHTTP(httptest.NewRecorder(), r)
+	return req, err
+}
+
+func TestBindRequest(t *testing.T) {
+	req, err := bindWith(t, "/orders/7?tag=a&tag=b", `{"id":1,"comment":"hi"}`)
+	if err != nil {
+		t.Fatalf("bindRequest() error = %v", err)
+	}
+
+	if req.ID != 7 {
+		t.Errorf("ID = %d, want the uri param override the body", req.ID)
+	}
+	if req.Status != "pending" || req.Comment != "hi" || req.Token != "secret" {
+		t.Errorf("bound request = %+v", req)
+	}
+	if len(req.Tags) != 2 || req.Tags[1] != "b" {
+		t.Errorf("Tags = %v, want [a b]", req.Tags)
+	}
+}
+
+func TestBindRequestError(t *testing.T) {
+	_, err := bindWith(t, "/orders/abc", `{}`)
+	bindErr, ok := err.(*BindError)
+	if !ok {
+		t.Fatalf("bindRequest() error = %v, want *BindError", err)
+	}
+
+	if bindErr.Source != SourceURI || bindErr.Field != "ID" || bindErr.Key != "id" {
+		t.Errorf("BindError = %+v", bindErr)
+	}
+}
+
+func TestValidationErrorResponse(t *testing.T) {
+	type createReq struct {
+		Name   string `json:"name" binding:"required"`
+		Amount uint64 `json:"amount" binding:"min=10"`
+	}
+
+	h := NewHandler(nil, nil, nil).SetValidationErrCode(400)
+	fun := h.HandleMiddleware(func(c *gin.Context, req *createReq) error { return nil })
+
+	resp := serve(http.MethodPost, "/create", `{"amount":1}`, fun)
+	if resp.Code != 400 {
+		t.Fatalf("code = %d, want 400", resp.Code)
+	}
+
+	want := []FieldError{
+		{Field: "Amount", Path: "amount", Rule: "min", Param: "10", Message: "amount must be at least 10"},
+		{Field: "Name", Path: "name", Rule: "required", Message: "name is required"},
+	}
+	if !reflect.DeepEqual(resp.Errors, want) {
+		t.Errorf("errors = %+v, want %+v", resp.Errors, want)
+	}
+}
+
+func TestBindDefaultAfterBody(t *testing.T) {
+	req, err := bindWith(t, "/orders/7", `{"status":"paid"}`)
+	if err != nil || req.Status != "paid" {
+		t.Errorf("Status = %q, %v, want the body value kept over the default", req.Status, err)
+	}
+}
+
+func TestBindUnsupportedContentType(t *testing.T) {
+	var err error
+	engine := gin.New()
+	engine.POST("/orders/:id", func(c *gin.Context) {
+		err = bindRequest(c, new(bindReq))
+	})
+
+	for contentType, wantErr := range map[string]bool{"text/plain": true, "application/xml": true, "application/merge-patch+json": false} {
+		r := httptest.NewRequest(http.MethodPost, "/orders/7", strings.NewReader(`{"status":"paid"}`))
+		r.Header.Set("Content-Type", contentType)
+		engine.ServeHTTP(httptest.NewRecorder(), r)
+		if gotErr := errors.Is(err, errUnsupportedContentType); gotErr != wantErr {
+			t.Errorf("bind %s error = %v, want unsupported %v", contentType, err, wantErr)
+		}
+	}
+}
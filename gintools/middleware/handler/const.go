@@ -3,4 +3,9 @@ package handler
 const (
 	// ReqBodyLabel represent the key of request body which set in gin context
 	ReqBodyLabel = "request_body_label"
+	// ContextKeyClient represent the key of the authenticated client name which set in gin context by the auth filters
+	ContextKeyClient = "auth_client"
+
+	// frontFilteredLabel represent the key of the handler whose front filters have been applied to the request
+	frontFilteredLabel = "front_filtered_label"
 )
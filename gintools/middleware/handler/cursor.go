@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	cursorDirectionNext = "next"
+	cursorDirectionPrev = "prev"
+)
+
+// ErrInvalidCursor is returned when the pagination cursor is malformed or its signature mismatched
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Cursor is the position of the keyset pagination, which holds the sort keys of the boundary item.
+// The numbers in a decoded cursor are json.Number to keep their precision.
+type Cursor map[string]interface{}
+
+// String give the key return the string value of the cursor
+func (c Cursor) String(key string) (string, bool) {
+	val, ok := c[key].(string)
+	return val, ok
+}
+
+// Int64 give the key return the int64 value of the cursor
+func (c Cursor) Int64(key string) (int64, bool) {
+	switch val := c[key].(type) {
+	case json.Number:
+		i, err := val.Int64()
+		return i, err == nil
+	case int64:
+		return val, true
+	case int:
+		return int64(val), true
+	}
+	return 0, false
+}
+
+// Uint64 give the key return the uint64 value of the cursor
+func (c Cursor) Uint64(key string) (uint64, bool) {
+	switch val := c[key].(type) {
+	case json.Number:
+		u, err := strconv.ParseUint(val.String(), 10, 64)
+		return u, err == nil
+	case uint64:
+		return val, true
+	case uint:
+		return uint64(val), true
+	}
+	return 0, false
+}
+
+// CursorQuery is the conditions for cursor paging query, After and Before are both nil for the first page
+type CursorQuery struct {
+	Limit  uint64
+	After  Cursor
+	Before Cursor
+
+	token string
+}
+
+// CursorResult used to return the cursor pagination info
+type CursorResult struct {
+	data interface{}
+	next Cursor
+	prev Cursor
+}
+
+// NewCursorResult is a factory method of CursorResult, next is the cursor of the last item if there are more items,
+// and prev is the cursor of the first item if it is not the first page
+func NewCursorResult(data interface{}, next, prev Cursor) *CursorResult {
+	return &CursorResult{data: data, next: next, prev: prev}
+}
+
+// CursorPagination is the response struct of the cursor pagination
+type CursorPagination struct {
+	Limit uint64 `json:"limit" xml:"limit"`
+	Next  string `json:"next,omitempty" xml:"next,omitempty"`
+	Prev  string `json:"prev,omitempty" xml:"prev,omitempty"`
+}
+
+// cursorToken is the payload of an encoded cursor
+type cursorToken struct {
+	Direction string `json:"d"`
+	Keys      Cursor `json:"k"`
+}
+
+// processCursorSecret is the random key which signs the cursors of the codecs without Secret
+var (
+	processCursorSecret     []byte
+	processCursorSecretOnce sync.Once
+)
+
+// CursorCodec encode the cursor to an opaque token signed with HMAC-SHA256. If the Secret is not set, the token
+// is signed with a random key generated per process, which is not accepted by the other processes or after a
+// restart, so set the Secret for the services of multiple instances. A nil codec is the same as the one without Secret.
+type CursorCodec struct {
+	Secret []byte
+}
+
+// NewCursorCodec return a cursor codec signing the tokens with the secret
+func NewCursorCodec(secret []byte) *CursorCodec {
+	return &CursorCodec{Secret: secret}
+}
+
+// Encode encode the cursor of the direction to a token
+func (cc *CursorCodec) Encode(direction string, cursor Cursor) (string, error) {
+	payload, err := json.Marshal(cursorToken{Direction: direction, Keys: cursor})
+	if err != nil {
+		return "", errors.Wrap(err, "marshal cursor")
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(payload)
+	return token + "." + base64.RawURLEncoding.EncodeToString(cc.sign(payload)), nil
+}
+
+// Decode decode the token, and return the direction and the cursor
+func (cc *CursorCodec) Decode(token string) (string, Cursor, error) {
+	parts := strings.SplitN(token, ".", 2)
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", nil, errors.Wrap(ErrInvalidCursor, err)
+	}
+
+	if len(parts) != 2 {
+		return "", nil, errors.Wrap(ErrInvalidCursor, "missing signature")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, cc.sign(payload)) {
+		return "", nil, errors.Wrap(ErrInvalidCursor, "signature mismatched")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var t cursorToken
+	if err := decoder.Decode(&t); err != nil {
+		return "", nil, errors.Wrap(ErrInvalidCursor, err)
+	}
+
+	if t.Direction != cursorDirectionNext && t.Direction != cursorDirectionPrev {
+		return "", nil, errors.Wrap(ErrInvalidCursor, "unknown direction")
+	}
+	return t.Direction, t.Keys, nil
+}
+
+func (cc *CursorCodec) sign(payload []byte) []byte {
+	var secret []byte
+	if cc != nil {
+		secret = cc.Secret
+	}
+	if len(secret) == 0 {
+		secret = cursorProcessSecret()
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// cursorProcessSecret return the random key of the process, which is generated at the first use
+func cursorProcessSecret() []byte {
+	processCursorSecretOnce.Do(func() {
+		processCursorSecret = make([]byte, 32)
+		if _, err := rand.Read(processCursorSecret); err != nil {
+			panic(errors.Wrap(err, "generate cursor secret"))
+		}
+	})
+	return processCursorSecret
+}
+
+// ParseCursor parse the cursor and limit query of the cursor pagination request
+func ParseCursor(c *gin.Context, codec *CursorCodec) (*CursorQuery, error) {
+	return ParseCursorWithOptions(c, codec, DefaultPaginationOptions)
+}
+
+// ParseCursorWithOptions parse the cursor pagination request, the limit is parsed by the options,
+// and a malformed or tampered cursor is responded as a validation error
+func ParseCursorWithOptions(c *gin.Context, codec *CursorCodec, opts PaginationOptions) (*CursorQuery, error) {
+	opts = opts.withDefaults()
+	limit, err := opts.parseLimit(c, "limit")
+	if err != nil {
+		return nil, err
+	}
+
+	token := c.Query("cursor")
+	query := &CursorQuery{Limit: limit, token: token}
+	if token == "" {
+		return query, nil
+	}
+
+	direction, cursor, err := codec.Decode(token)
+	if err != nil {
+		return nil, errors.Wrap(paginationParamError("cursor", "cursor", "", "cursor is invalid"), err)
+	}
+
+	if direction == cursorDirectionNext {
+		query.After = cursor
+	} else {
+		query.Before = cursor
+	}
+	return query, nil
+}
+
+// CursorProcessor is the middle result of cursor paging query
+type CursorProcessor struct {
+	*CursorPagination
+	current string
+}
+
+// NewCursorProcessor create a new CursorProcessor, the cursors of the result are encoded by the codec
+func NewCursorProcessor(query *CursorQuery, result *CursorResult, codec *CursorCodec) (*CursorProcessor, error) {
+	p := &CursorProcessor{CursorPagination: &CursorPagination{Limit: query.Limit}, current: query.token}
+
+	var err error
+	if result.next != nil {
+		if p.Next, err = codec.Encode(cursorDirectionNext, result.next); err != nil {
+			return nil, err
+		}
+	}
+
+	if result.prev != nil {
+		if p.Prev, err = codec.Encode(cursorDirectionPrev, result.prev); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// getLinks return the calculated CursorProcessor links
+func (p *CursorProcessor) getLinks(link linkFunc) links {
+	page := func(cursor string) string {
+		params := map[string]string{"limit": strconv.FormatUint(p.Limit, 10)}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		return link(params)
+	}
+
+	l := links{Self: page(p.current), First: page("")}
+	if p.Next != "" {
+		l.Next = page(p.Next)
+	}
+
+	if p.Prev != "" {
+		l.Prev = page(p.Prev)
+	}
+	return l
+}
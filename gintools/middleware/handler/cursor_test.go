@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := &CursorCodec{Secret: []byte("secret")}
+	token, err := codec.Encode(cursorDirectionNext, Cursor{"height": uint64(18446744073709551615), "hash": "ab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	direction, cursor, err := codec.Decode(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	height, _ := cursor.Uint64("height")
+	hash, _ := cursor.String("hash")
+	if direction != cursorDirectionNext || height != 18446744073709551615 || hash != "ab" {
+		t.Errorf("Decode() = %s %v", direction, cursor)
+	}
+
+	if _, _, err := (&CursorCodec{Secret: []byte("other")}).Decode(token); errors.Root(err) != ErrInvalidCursor {
+		t.Errorf("Decode() with other secret error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
+
+func TestCursorPagination(t *testing.T) {
+	codec := &CursorCodec{Secret: []byte("secret")}
+	h := NewHandler(nil, nil, nil).SetCursorCodec(codec)
+
+	fun := h.HandleMiddleware(func(c *gin.Context, query *CursorQuery) (*CursorResult, error) {
+		after, _ := query.After.Uint64("height")
+		list := []uint64{after + 1, after + 2}
+		return NewCursorResult(list, Cursor{"height": list[1]}, Cursor{"height": list[0]}), nil
+	})
+
+	resp := serve(http.MethodGet, "/blocks?limit=2", "", fun)
+	if resp.Pagination == nil || resp.Pagination.Cursor == nil || resp.Pagination.Cursor.Next == "" {
+		t.Fatalf("pagination = %+v, want next cursor", resp.Pagination)
+	}
+
+	next, err := url.Parse(resp.Pagination.Links.Next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp = serve(http.MethodGet, next.String(), "", fun)
+	data := resp.Data.([]interface{})
+	if len(data) != 2 || data[0].(float64) != 3 {
+		t.Errorf("data of the next page = %v, want [3 4]", data)
+	}
+}
+
+func TestCursorCodecTampering(t *testing.T) {
+	codec := &CursorCodec{}
+	token, err := codec.Encode(cursorDirectionNext, Cursor{"height": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := codec.Decode(token); err != nil {
+		t.Fatalf("Decode() error = %v, want the token signed with the process key accepted", err)
+	}
+
+	payload, _ := json.Marshal(cursorToken{Direction: cursorDirectionNext, Keys: Cursor{"height": 0}})
+	forged := base64.RawURLEncoding.EncodeToString(payload)
+	signature := token[strings.Index(token, ".")+1:]
+	for name, tampered := range map[string]string{"unsigned": forged, "forged payload": forged + "." + signature} {
+		if _, _, err := codec.Decode(tampered); errors.Root(err) != ErrInvalidCursor {
+			t.Errorf("Decode(%s) error = %v, want %v", name, err, ErrInvalidCursor)
+		}
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetValidationErrCode(400)
+	fun := h.HandleMiddleware(func(c *gin.Context, query *CursorQuery) (*CursorResult, error) {
+		return NewCursorResult([]uint64{}, nil, nil), nil
+	})
+
+	resp := serve(http.MethodGet, "/blocks?cursor=tampered", "", fun)
+	if resp.Code != 400 || len(resp.Errors) != 1 || resp.Errors[0].Path != "cursor" {
+		t.Errorf("response = %+v, want a validation error of cursor", resp)
+	}
+}
+
+func TestParseCursorNilCodec(t *testing.T) {
+	token, err := (&CursorCodec{}).Encode(cursorDirectionPrev, Cursor{"height": 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := gin.New()
+	engine.GET("/blocks", func(c *gin.Context) {
+		query, err := ParseCursor(c, nil)
+		if err != nil {
+			c.String(http.StatusBadRequest, err.Error())
+			return
+		}
+		height, _ := query.Before.Int64("height")
+		c.String(http.StatusOK, strconv.FormatInt(height, 10))
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/blocks?cursor="+token, nil))
+	if w.Code != http.StatusOK || w.Body.String() != "7" {
+		t.Errorf("ParseCursor() with nil codec = %d %s, want 7", w.Code, w.Body.String())
+	}
+}
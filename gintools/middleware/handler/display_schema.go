@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// FilterType is the type of a filter value
+type FilterType string
+
+// the types of filter value
+const (
+	FilterString  FilterType = "string"
+	FilterNumber  FilterType = "number"
+	FilterBoolean FilterType = "boolean"
+	FilterTime    FilterType = "time"
+)
+
+// the operators of filter
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpContains = "contains"
+)
+
+// typeOperators is the operators allowed by default for each filter type
+var typeOperators = map[FilterType][]string{
+	FilterString:  {OpEq, OpNe, OpIn, OpContains},
+	FilterNumber:  {OpEq, OpNe, OpIn, OpGt, OpGte, OpLt, OpLte},
+	FilterBoolean: {OpEq, OpNe},
+	FilterTime:    {OpEq, OpNe, OpGt, OpGte, OpLt, OpLte},
+}
+
+// FilterField describe a filter key which the client is allowed to use
+type FilterField struct {
+	Type FilterType
+	// Operators is the allowed operators, all the operators of the type are allowed if it is empty
+	Operators []string
+	// Column is the field name in storage, the filter key is used if it is empty
+	Column string
+}
+
+// SortField describe a field which the client is allowed to sort by
+type SortField struct {
+	// Column is the field name in storage, the sort key is used if it is empty
+	Column string
+	// Directions is the allowed directions, both directions are allowed if it is empty
+	Directions []string
+}
+
+// FilterSchema declare the filters and sorts a Display is allowed to contain. The filter is either the flat format,
+// such as {"amount": {"gte": 10, "lt": 100}, "asset": {"in": ["BTM", "BTC"]}}, whose value is compared by "eq" if
+// it is not an object of operator to value, or the boolean expression described in FilterExpr.
+type FilterSchema struct {
+	Fields map[string]FilterField
+	Sorts  map[string]SortField
+	// MaxSortKeys limit the number of the sort keys, it is unlimited if it is zero
+	MaxSortKeys int
+	// Tiebreaker is the unique column appended to the sorts in ascending order if it is not sorted by, even if
+	// the client sends no sort, so the paginated results are in a stable order
+	Tiebreaker string
+}
+
+// DisplayGetter is implemented by the requests which embed Display
+type DisplayGetter interface {
+	GetDisplay() *Display
+}
+
+// GetDisplay return the display itself, so the requests embedding Display implement DisplayGetter
+func (d *Display) GetDisplay() *Display {
+	return d
+}
+
+// displaySort is a validated sort of the display
+type displaySort struct {
+	column    string
+	direction string
+	nulls     string
+}
+
+// RequestFilter return a RequestFilter which validate the Display of the requests
+func (s *FilterSchema) RequestFilter() RequestFilter {
+	return func(ctx *gin.Context, req interface{}) error {
+		getter, ok := req.(DisplayGetter)
+		if !ok {
+			return nil
+		}
+		return s.Validate(getter.GetDisplay())
+	}
+}
+
+// Validate check the filters and sort of the display, and return ValidationError if any of them is not allowed
+func (s *FilterSchema) Validate(d *Display) error {
+	_, _, err := s.parse(d)
+	return err
+}
+
+// Expr parse the filter of the display into an expression, whose comparisons are validated by the schema
+func (s *FilterSchema) Expr(d *Display) (FilterExpr, error) {
+	expr, _, err := s.parse(d)
+	return expr, err
+}
+
+// Bson translate the display into the mgo query and sort fields. The Nulls of the sort keys are ignored,
+// because MongoDB always orders the null values as the smallest ones.
+func (s *FilterSchema) Bson(d *Display) (bson.M, []string, error) {
+	expr, sorts, err := s.parse(d)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sortFields []string
+	for _, srt := range sorts {
+		if srt.direction == SortDesc {
+			sortFields = append(sortFields, "-"+srt.column)
+		} else {
+			sortFields = append(sortFields, srt.column)
+		}
+	}
+	return BsonFilter(expr), sortFields, nil
+}
+
+// SQL translate the display into the WHERE and ORDER BY fragments for gorm, the values are bound by placeholders,
+// such as db.Where(where, args...).Order(orderBy)
+func (s *FilterSchema) SQL(d *Display) (where string, args []interface{}, orderBy string, err error) {
+	expr, sorts, err := s.parse(d)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	var orders []string
+	for _, srt := range sorts {
+		// the portable form of NULLS FIRST and NULLS LAST, which MySQL does not support
+		switch srt.nulls {
+		case NullsFirst:
+			orders = append(orders, srt.column+" IS NOT NULL")
+		case NullsLast:
+			orders = append(orders, srt.column+" IS NULL")
+		}
+		orders = append(orders, fmt.Sprintf("%s %s", srt.column, strings.ToUpper(srt.direction)))
+	}
+
+	where, args = SQLFilter(expr)
+	return where, args, strings.Join(orders, ", "), nil
+}
+
+// parse validate the display, and return the filter expression and the sorts
+func (s *FilterSchema) parse(d *Display) (FilterExpr, []displaySort, error) {
+	expr, err := parseFilterExpr(d.Filter, s)
+	var fieldErrs []FieldError
+	if err != nil {
+		fieldErrs = append(fieldErrs, err.(*ValidationError).Fields...)
+	}
+
+	sorts, errs := s.parseSort(d.Sorter)
+	fieldErrs = append(fieldErrs, errs...)
+	if len(fieldErrs) != 0 {
+		return nil, nil, &ValidationError{Fields: fieldErrs}
+	}
+	return expr, sorts, nil
+}
+
+func (s *FilterSchema) parseSort(sorter Sorter) ([]displaySort, []FieldError) {
+	keys := sorter.SortKeys()
+	if s.MaxSortKeys != 0 && len(keys) > s.MaxSortKeys {
+		maxKeys := fmt.Sprint(s.MaxSortKeys)
+		return nil, []FieldError{displayFieldError("Keys", "sort", "max", maxKeys, fmt.Sprintf("sort must contain at most %s keys", maxKeys))}
+	}
+
+	var (
+		sorts     []displaySort
+		fieldErrs []FieldError
+		sorted    = make(map[string]bool)
+	)
+	for i, key := range keys {
+		path := "sort"
+		if len(sorter.Keys) != 0 {
+			path = fmt.Sprintf("sort[%d]", i)
+		}
+
+		srt, err := s.parseSortKey(key, path)
+		if err != nil {
+			fieldErrs = append(fieldErrs, *err)
+			continue
+		}
+
+		if sorted[srt.column] {
+			fieldErrs = append(fieldErrs, displayFieldError("By", path+".by", "unique", "", "sort by "+key.By+" is duplicated"))
+			continue
+		}
+		sorted[srt.column] = true
+		sorts = append(sorts, srt)
+	}
+
+	if len(fieldErrs) != 0 {
+		return nil, fieldErrs
+	}
+
+	if s.Tiebreaker != "" && !sorted[s.Tiebreaker] {
+		sorts = append(sorts, displaySort{column: s.Tiebreaker, direction: SortAsc})
+	}
+	return sorts, nil
+}
+
+func (s *FilterSchema) parseSortKey(key SortKey, path string) (displaySort, *FieldError) {
+	field, ok := s.Sorts[key.By]
+	if !ok {
+		err := displayFieldError("By", path+".by", "whitelist", "", "sort by "+key.By+" is not allowed")
+		return displaySort{}, &err
+	}
+
+	direction, ok := NormalizeSortDirection(key.Order)
+	if !ok || (len(field.Directions) != 0 && !containsString(field.Directions, direction)) {
+		err := displayFieldError("Order", path+".order", "direction", key.Order, "sort order "+key.Order+" is not allowed")
+		return displaySort{}, &err
+	}
+
+	nulls := strings.ToLower(key.Nulls)
+	if nulls != "" && nulls != NullsFirst && nulls != NullsLast {
+		err := displayFieldError("Nulls", path+".nulls", "oneof", NullsFirst+" "+NullsLast, "sort nulls must be first or last")
+		return displaySort{}, &err
+	}
+
+	column := field.Column
+	if column == "" {
+		column = key.By
+	}
+	return displaySort{column: column, direction: direction, nulls: nulls}, nil
+}
+
+func (f FilterField) allows(op string) bool {
+	if len(f.Operators) != 0 {
+		return containsString(f.Operators, op)
+	}
+	return containsString(typeOperators[f.Type], op)
+}
+
+// coerce check the type of the operand, and convert it to the value used in storage
+func (f FilterField) coerce(op string, operand interface{}) (interface{}, error) {
+	if op != OpIn {
+		return f.coerceValue(operand)
+	}
+
+	list, ok := operand.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("must be a non-empty array")
+	}
+
+	values := make([]interface{}, len(list))
+	for i, item := range list {
+		value, err := f.coerceValue(item)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func (f FilterField) coerceValue(operand interface{}) (interface{}, error) {
+	switch f.Type {
+	case FilterString:
+		if val, ok := operand.(string); ok {
+			return val, nil
+		}
+
+	case FilterBoolean:
+		if val, ok := operand.(bool); ok {
+			return val, nil
+		}
+
+	case FilterNumber:
+		if val, ok := normalizeNumber(operand); ok {
+			return val, nil
+		}
+
+	case FilterTime:
+		if t, ok := parseFilterTime(operand); ok {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("must be a %s", f.Type)
+}
+
+// normalizeNumber convert the decoded json number to int64 if it is integral, to uint64 if it is integral
+// above math.MaxInt64 to keep the precision of the amounts, otherwise float64
+func normalizeNumber(operand interface{}) (interface{}, bool) {
+	switch val := operand.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i, true
+		}
+		if u, err := strconv.ParseUint(val.String(), 10, 64); err == nil {
+			return u, true
+		}
+		f, err := val.Float64()
+		return f, err == nil
+	case float64:
+		if val == float64(int64(val)) {
+			return int64(val), true
+		}
+		return val, true
+	case int:
+		return int64(val), true
+	case int64:
+		return val, true
+	case uint64:
+		if val <= math.MaxInt64 {
+			return int64(val), true
+		}
+		return val, true
+	}
+	return nil, false
+}
+
+func displayFieldError(field, path, rule, param, message string) FieldError {
+	return FieldError{Field: field, Path: path, Source: SourceJSON, Rule: rule, Param: param, Message: message}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
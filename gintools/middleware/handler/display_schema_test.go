@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+var testSchema = &FilterSchema{
+	Fields: map[string]FilterField{
+		"asset":   {Type: FilterString, Column: "asset_id"},
+		"amount":  {Type: FilterNumber},
+		"status":  {Type: FilterString, Operators: []string{OpEq, OpIn}},
+		"created": {Type: FilterTime, Column: "created_at"},
+	},
+	Sorts: map[string]SortField{
+		"amount":  {},
+		"created": {Column: "created_at", Directions: []string{SortDesc}},
+	},
+}
+
+func parseDisplay(t *testing.T, s string) *Display {
+	d := &Display{}
+	if err := json.Unmarshal([]byte(s), d); err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestFilterSchemaBson(t *testing.T) {
+	d := parseDisplay(t, `{"filter":{"asset":"BTM","amount":{"gte":10,"lt":100.5},"status":{"in":["ok","pending"]}},"sort":{"by":"amount","order":"desc"}}`)
+	query, sorts, err := testSchema.Bson(d)
+	if err != nil {
+		t.Fatalf("Bson() error = %v", err)
+	}
+
+	want := bson.M{
+		"asset_id": bson.M{"$eq": "BTM"},
+		"amount":   bson.M{"$gte": int64(10), "$lt": 100.5},
+		"status":   bson.M{"$in": []interface{}{"ok", "pending"}},
+	}
+	if !reflect.DeepEqual(query, want) {
+		t.Errorf("query = %v, want %v", query, want)
+	}
+	if !reflect.DeepEqual(sorts, []string{"-amount"}) {
+		t.Errorf("sorts = %v, want [-amount]", sorts)
+	}
+}
+
+func TestFilterSchemaSQL(t *testing.T) {
+	d := parseDisplay(t, `{"filter":{"asset":{"contains":"a_b"},"created":{"gt":"2020-01-02T00:00:00Z"},"status":{"in":["ok","pending"]}},"sort":{"by":"created","order":"desc"}}`)
+	where, args, orderBy, err := testSchema.SQL(d)
+	if err != nil {
+		t.Fatalf("SQL() error = %v", err)
+	}
+
+	if want := "asset_id LIKE ? AND created_at > ? AND status IN (?, ?)"; where != want {
+		t.Errorf("where = %q, want %q", where, want)
+	}
+	wantArgs := []interface{}{`%a\_b%`, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), "ok", "pending"}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("args = %v, want %v", args, wantArgs)
+	}
+	if orderBy != "created_at DESC" {
+		t.Errorf("orderBy = %q, want created_at DESC", orderBy)
+	}
+}
+
+func TestFilterSchemaValidate(t *testing.T) {
+	cases := []struct {
+		display string
+		paths   []string
+	}{
+		{display: `{"filter":{"amount":1}}`},
+		{display: `{"filter":{"unknown":1}}`, paths: []string{"filter.unknown"}},
+		{display: `{"filter":{"status":{"ne":"ok"}}}`, paths: []string{"filter.status.ne"}},
+		{display: `{"filter":{"amount":"1"}}`, paths: []string{"filter.amount.eq"}},
+		{display: `{"filter":{"status":{"in":[]}}}`, paths: []string{"filter.status.in"}},
+		{display: `{"sort":{"by":"asset"}}`, paths: []string{"sort.by"}},
+		{display: `{"sort":{"by":"created","order":"asc"}}`, paths: []string{"sort.order"}},
+		{display: `{"filter":{"amount":true,"asset":1}}`, paths: []string{"filter.amount.eq", "filter.asset.eq"}},
+	}
+
+	for i, c := range cases {
+		err := testSchema.RequestFilter()(nil, parseDisplay(t, c.display))
+		if len(c.paths) == 0 {
+			if err != nil {
+				t.Errorf("case %d: error = %v, want nil", i, err)
+			}
+			continue
+		}
+
+		validationErr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("case %d: error = %v, want *ValidationError", i, err)
+			continue
+		}
+
+		var paths []string
+		for _, field := range validationErr.Fields {
+			paths = append(paths, field.Path)
+		}
+		if !reflect.DeepEqual(paths, c.paths) {
+			t.Errorf("case %d: paths = %v, want %v", i, paths, c.paths)
+		}
+	}
+}
+
+func TestMultiKeySort(t *testing.T) {
+	schema := &FilterSchema{
+		Sorts:       map[string]SortField{"amount": {}, "created": {Column: "created_at"}, "id": {}},
+		MaxSortKeys: 3,
+		Tiebreaker:  "id",
+	}
+
+	d := parseDisplay(t, `{"sort":[{"by":"amount","order":"-1","nulls":"last"},{"by":"created","order":"1","nulls":"first"}]}`)
+	_, sorts, err := schema.Bson(d)
+	if err != nil {
+		t.Fatalf("Bson() error = %v", err)
+	}
+	if want := []string{"-amount", "created_at", "id"}; !reflect.DeepEqual(sorts, want) {
+		t.Errorf("sorts = %v, want %v", sorts, want)
+	}
+
+	_, _, orderBy, err := schema.SQL(d)
+	if err != nil {
+		t.Fatalf("SQL() error = %v", err)
+	}
+	if want := "amount IS NULL, amount DESC, created_at IS NOT NULL, created_at ASC, id ASC"; orderBy != want {
+		t.Errorf("orderBy = %q, want %q", orderBy, want)
+	}
+
+	_, _, orderBy, _ = schema.SQL(parseDisplay(t, `{"sort":{"keys":[{"by":"id","order":"DESC"}]}}`))
+	if orderBy != "id DESC" {
+		t.Errorf("orderBy = %q, want the tiebreaker not appended twice", orderBy)
+	}
+
+	_, sorts, _ = schema.Bson(parseDisplay(t, `{}`))
+	if want := []string{"id"}; !reflect.DeepEqual(sorts, want) {
+		t.Errorf("unsorted sorts = %v, want the tiebreaker %v", sorts, want)
+	}
+
+	cases := []struct {
+		display string
+		paths   []string
+	}{
+		{display: `{"sort":[{"by":"amount"},{"by":"amount","order":"desc"}]}`, paths: []string{"sort[1].by"}},
+		{display: `{"sort":[{"by":"amount","order":"up"},{"by":"id","nulls":"middle"}]}`, paths: []string{"sort[0].order", "sort[1].nulls"}},
+		{display: `{"sort":[{"by":"amount"},{"by":"created"},{"by":"id"},{"by":"memo"}]}`, paths: []string{"sort"}},
+	}
+
+	for i, c := range cases {
+		err := schema.Validate(parseDisplay(t, c.display))
+		validationErr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("case %d: error = %v, want *ValidationError", i, err)
+			continue
+		}
+
+		var paths []string
+		for _, field := range validationErr.Fields {
+			paths = append(paths, field.Path)
+		}
+		if !reflect.DeepEqual(paths, c.paths) {
+			t.Errorf("case %d: paths = %v, want %v", i, paths, c.paths)
+		}
+	}
+}
+
+func TestSorterKeys(t *testing.T) {
+	d := parseDisplay(t, `{"sort":{"by":"amount","order":"desc"}}`)
+	if keys := d.GetSortKeys(); !reflect.DeepEqual(keys, []SortKey{{By: "amount", Order: "desc"}}) {
+		t.Errorf("GetSortKeys() = %v, want the single key of By and Order", keys)
+	}
+
+	d = parseDisplay(t, `{"sort":[{"by":"amount","order":"desc"},{"by":"id"}]}`)
+	if keys := d.GetSortKeys(); len(keys) != 2 || keys[1].By != "id" {
+		t.Errorf("GetSortKeys() = %v, want the keys of the array form", keys)
+	}
+
+	for order, want := range map[string]string{"": SortAsc, "ASC": SortAsc, "1": SortAsc, "-1": SortDesc, "Descending": SortDesc} {
+		if got, ok := NormalizeSortDirection(order); !ok || got != want {
+			t.Errorf("NormalizeSortDirection(%q) = %q, %v, want %q", order, got, ok, want)
+		}
+	}
+	if _, ok := NormalizeSortDirection("random"); ok {
+		t.Error("NormalizeSortDirection(random) is ok, want rejected")
+	}
+}
+
+func TestFilterSchemaLargeAmount(t *testing.T) {
+	d := parseDisplay(t, `{"filter":{"amount":{"gte":18446744073709551615}}}`)
+	query, _, err := testSchema.Bson(d)
+	if err != nil {
+		t.Fatalf("Bson() error = %v", err)
+	}
+	if want := (bson.M{"amount": bson.M{"$gte": uint64(18446744073709551615)}}); !reflect.DeepEqual(query, want) {
+		t.Errorf("query = %v, want the max uint64 without precision loss", query)
+	}
+
+	_, args, _, err := testSchema.SQL(d)
+	if err != nil || !reflect.DeepEqual(args, []interface{}{uint64(18446744073709551615)}) {
+		t.Errorf("SQL() args = %v, %v, want the max uint64", args, err)
+	}
+}
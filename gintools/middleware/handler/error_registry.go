@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"math"
+	"reflect"
+	"sort"
+
+	"github.com/bytom/bytom/errors"
+)
+
+// the kinds of error rule, a lower kind is more specific when the matched errors are at the same depth
+const (
+	ruleKindSentinel = iota
+	ruleKindType
+	ruleKindPredicate
+)
+
+// ErrorClass is a sentinel error which represent a class of errors, the sub-classes of it match it with errors.Is.
+// It allows registering a generic ErrorSpec for the class which can be overridden by a more specific one.
+type ErrorClass struct {
+	msg    string
+	parent *ErrorClass
+}
+
+// NewErrorClass return a root error class
+func NewErrorClass(msg string) *ErrorClass {
+	return &ErrorClass{msg: msg}
+}
+
+// Sub return a sub-class of the error class
+func (c *ErrorClass) Sub(msg string) *ErrorClass {
+	return &ErrorClass{msg: msg, parent: c}
+}
+
+// Error satisfies the error interface
+func (c *ErrorClass) Error() string {
+	return c.msg
+}
+
+// Is report whether the target is the error class itself or one of its ancestors
+func (c *ErrorClass) Is(target error) bool {
+	_, ok := c.distance(target)
+	return ok
+}
+
+// distance return the hops from the error class to the target ancestor
+func (c *ErrorClass) distance(target error) (int, bool) {
+	for hops, class := 0, c; class != nil; hops, class = hops+1, class.parent {
+		if error(class) == target {
+			return hops, true
+		}
+	}
+	return 0, false
+}
+
+type errorRule struct {
+	kind  int
+	spec  ErrorSpec
+	desc  string
+	match func(err error) (distance int, ok bool)
+}
+
+// ErrorRegistry resolve the ErrorSpec of an error by walking its chain, which contains the root of this repo's
+// wrapped errors and the errors unwrapped by errors.Unwrap. When several rules match, the one matching nearest
+// the head of the chain wins, then sentinels win over types and types win over predicates, and at last the
+// later registered rule wins. The registry is not safe for concurrent registration.
+type ErrorRegistry struct {
+	rules []errorRule
+}
+
+// NewErrorRegistry return an empty error registry
+func NewErrorRegistry() *ErrorRegistry {
+	return &ErrorRegistry{}
+}
+
+// clone return a copy of the registry, the rules registered to the copy do not affect the origin
+func (r *ErrorRegistry) clone() *ErrorRegistry {
+	return &ErrorRegistry{rules: append([]errorRule(nil), r.rules...)}
+}
+
+// NewErrorRegistryFromSpecs return an error registry with the sentinel errors registered in the order of the
+// error code and the error text, so that the tied rules are resolved the same way on every run
+func NewErrorRegistryFromSpecs(errorSpecs map[error]ErrorSpec) *ErrorRegistry {
+	return NewErrorRegistry().registerSpecs(errorSpecs)
+}
+
+// registerSpecs register the sentinel errors in the order of the error code and the error text
+func (r *ErrorRegistry) registerSpecs(errorSpecs map[error]ErrorSpec) *ErrorRegistry {
+	targets := make([]error, 0, len(errorSpecs))
+	for target := range errorSpecs {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		ci, cj := errorSpecs[targets[i]].Code, errorSpecs[targets[j]].Code
+		if ci != cj {
+			return ci < cj
+		}
+		return errorText(targets[i]) < errorText(targets[j])
+	})
+
+	for _, target := range targets {
+		r.Register(target, errorSpecs[target])
+	}
+	return r
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Register register the spec of a sentinel error, it matches the errors which is the target or is the target
+// by errors.Is, such as the sub-classes of an ErrorClass and the errors wrapped with fmt.Errorf("%w").
+// It panics if the target is nil, which matches no error.
+func (r *ErrorRegistry) Register(target error, spec ErrorSpec) *ErrorRegistry {
+	if target == nil {
+		panic("register the error spec of a nil error")
+	}
+
+	isComparable := reflect.TypeOf(target).Comparable()
+	r.rules = append(r.rules, errorRule{kind: ruleKindSentinel, spec: spec, desc: target.Error(), match: func(err error) (int, bool) {
+		return chainDistance(err, func(e error) (int, bool) {
+			if isComparable && e == target {
+				return 0, true
+			}
+
+			if class, ok := e.(*ErrorClass); ok {
+				return class.distance(target)
+			}
+
+			if x, ok := e.(interface{ Is(error) bool }); ok && x.Is(target) {
+				return 1, true
+			}
+			return 0, false
+		})
+	}})
+	return r
+}
+
+// RegisterFunc register the spec of the errors which satisfy the predicate, the predicate is the least specific rule
+func (r *ErrorRegistry) RegisterFunc(predicate func(err error) bool, spec ErrorSpec) *ErrorRegistry {
+	r.rules = append(r.rules, errorRule{kind: ruleKindPredicate, spec: spec, match: func(err error) (int, bool) {
+		return math.MaxInt32, predicate(err)
+	}})
+	return r
+}
+
+// RegisterType register the spec of the errors which are of type T, such as RegisterType[*mgo.QueryError]
+func RegisterType[T error](r *ErrorRegistry, spec ErrorSpec) *ErrorRegistry {
+	r.rules = append(r.rules, errorRule{kind: ruleKindType, spec: spec, desc: reflect.TypeOf((*T)(nil)).Elem().String(), match: func(err error) (int, bool) {
+		return chainDistance(err, func(e error) (int, bool) {
+			_, ok := e.(T)
+			return 0, ok
+		})
+	}})
+	return r
+}
+
+// Resolve return the spec of the most specific rule which matches the error
+func (r *ErrorRegistry) Resolve(err error) (ErrorSpec, bool) {
+	best, bestDistance := -1, 0
+	for i, rule := range r.rules {
+		distance, ok := rule.match(err)
+		if !ok {
+			continue
+		}
+
+		if best < 0 || distance < bestDistance || (distance == bestDistance && rule.kind <= r.rules[best].kind) {
+			best, bestDistance = i, distance
+		}
+	}
+
+	if best < 0 {
+		return ErrorSpec{}, false
+	}
+	return r.rules[best].spec, true
+}
+
+// Specs return the specs of the registered rules in registration order, the Message of a spec defaults to
+// the text of the sentinel error or the name of the error type, so that the specs are able to describe the API
+func (r *ErrorRegistry) Specs() []ErrorSpec {
+	specs := make([]ErrorSpec, 0, len(r.rules))
+	for _, rule := range r.rules {
+		spec := rule.spec
+		if spec.Message == "" {
+			spec.Message = rule.desc
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+// chainDistance walk the error chain and return the minimum distance the match function is satisfied,
+// the distance is the depth of the matched error in the chain plus the distance reported by match
+func chainDistance(err error, match func(e error) (int, bool)) (int, bool) {
+	best, found := 0, false
+	walkErrorChain(err, 0, func(e error, depth int) {
+		if distance, ok := match(e); ok && (!found || depth+distance < best) {
+			best, found = depth+distance, true
+		}
+	})
+	return best, found
+}
+
+func walkErrorChain(err error, depth int, visit func(e error, depth int)) {
+	for err != nil {
+		visit(err, depth)
+		depth++
+
+		if root := errors.Root(err); reflect.TypeOf(root) != reflect.TypeOf(err) {
+			err = root
+			continue
+		}
+
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range x.Unwrap() {
+				walkErrorChain(e, depth, visit)
+			}
+			return
+		default:
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/bytom/bytom/errors"
+)
+
+func TestErrorRegistryResolve(t *testing.T) {
+	errNotFound := NewErrorClass("not found")
+	errUserNotFound := errNotFound.Sub("user not found")
+	errAssetNotFound := errNotFound.Sub("asset not found")
+	errWrapped := fmt.Errorf("load config: %w", os.ErrNotExist)
+
+	r := NewErrorRegistry().
+		Register(errNotFound, ErrorSpec{Code: 404}).
+		Register(errUserNotFound, ErrorSpec{Code: 40401}).
+		Register(os.ErrNotExist, ErrorSpec{Code: 40402}).
+		RegisterFunc(func(err error) bool { return err.Error() == "timeout" }, ErrorSpec{Code: 504})
+	RegisterType[*os.PathError](r, ErrorSpec{Code: 500})
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantOK   bool
+	}{
+		{name: "specific sentinel overrides class", err: errors.Wrap(errUserNotFound, "get user"), wantCode: 40401, wantOK: true},
+		{name: "class", err: errAssetNotFound, wantCode: 404, wantOK: true},
+		{name: "fmt wrapped sentinel", err: errors.Wrap(errWrapped, "init"), wantCode: 40402, wantOK: true},
+		{name: "type nearer than sentinel", err: &os.PathError{Op: "open", Err: os.ErrNotExist}, wantCode: 500, wantOK: true},
+		{name: "predicate", err: fmt.Errorf("timeout"), wantCode: 504, wantOK: true},
+		{name: "unmapped", err: fmt.Errorf("unknown"), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, ok := r.Resolve(tt.err)
+			if ok != tt.wantOK || spec.Code != tt.wantCode {
+				t.Errorf("Resolve() = %v %v, want %v %v", spec.Code, ok, tt.wantCode, tt.wantOK)
+			}
+		})
+	}
+}
+
+// ambiguousError is the error which is both of the targets
+type ambiguousError struct {
+	targets []error
+}
+
+func (e *ambiguousError) Error() string { return "ambiguous" }
+
+func (e *ambiguousError) Is(target error) bool {
+	for _, t := range e.targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNewErrorRegistryFromSpecsOrder(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	err := &ambiguousError{targets: []error{errA, errB}}
+	for i := 0; i < 20; i++ {
+		r := NewErrorRegistryFromSpecs(map[error]ErrorSpec{errB: {Code: 2}, errA: {Code: 1}})
+		if spec, _ := r.Resolve(err); spec.Code != 2 {
+			t.Fatalf("Resolve() = %d, want the tie resolved by the later registered code 2", spec.Code)
+		}
+	}
+}
+
+func TestWithErrorSpecsOrder(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	err := &ambiguousError{targets: []error{errA, errB}}
+	for i := 0; i < 20; i++ {
+		h := NewHandler(nil, nil, nil).With(WithErrorSpecs(map[error]ErrorSpec{errB: {Code: 2}, errA: {Code: 1}}))
+		if spec, _ := h.ErrorRegistry().Resolve(err); spec.Code != 2 {
+			t.Fatalf("Resolve() = %d, want the tie resolved by the later registered code 2", spec.Code)
+		}
+	}
+}
+
+func TestRegisterNilError(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Register(nil) does not panic")
+		}
+	}()
+	NewErrorRegistry().Register(nil, ErrorSpec{Code: 1})
+}
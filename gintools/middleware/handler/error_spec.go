@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorSpec describe how an error is responded, a zero HTTPStatus means the default status is used
+type ErrorSpec struct {
+	HTTPStatus int
+	Code       int
+	Message    string
+}
+
+// ErrorSpecAdaptor is a ResponseAdaptor which is able to respond the error with the whole ErrorSpec,
+// the handler prefers RespondErrorSpec over RespondErrorResp if the adaptor implements it
+type ErrorSpecAdaptor interface {
+	ResponseAdaptor
+	RespondErrorSpec(c *gin.Context, err error, spec ErrorSpec)
+}
+
+func errorSpecsFromCodes(errorCodes map[error]int) map[error]ErrorSpec {
+	errorSpecs := make(map[error]ErrorSpec, len(errorCodes))
+	for err, code := range errorCodes {
+		errorSpecs[err] = ErrorSpec{Code: code}
+	}
+	return errorSpecs
+}
+
+// errorSpec resolve the ErrorSpec of the error, and fill the default HTTP status: 400 for the mapped errors and
+// the binding errors, 413 for the too large body, 504 for the handler timeout, 500 for the unmapped errors
+func (h *Handler) errorSpec(err error) ErrorSpec {
+	spec, ok := h.errorRegistry.Resolve(err)
+	if !ok {
+		root := errors.Root(err)
+		if _, isValidation := root.(*ValidationError); isValidation {
+			spec, ok = ErrorSpec{Code: h.validationErrCode}, true
+		} else if root == ErrHandlerTimeout {
+			spec, ok = ErrorSpec{HTTPStatus: http.StatusGatewayTimeout, Code: h.timeoutErrCode}, true
+		}
+	}
+
+	if spec.HTTPStatus == 0 && errors.Root(err) == ErrBodyTooLarge {
+		spec.HTTPStatus = http.StatusRequestEntityTooLarge
+	}
+
+	if spec.HTTPStatus == 0 {
+		spec.HTTPStatus = http.StatusInternalServerError
+		if ok {
+			spec.HTTPStatus = http.StatusBadRequest
+		}
+	}
+	return spec
+}
+
+// defaultErrorStatus return the HTTP status of the error code which has no ErrorSpec
+func defaultErrorStatus(errCode int) int {
+	if errCode != 0 {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// respondError respond the error through the response adaptor
+func (h *Handler) respondError(context *gin.Context, err error) {
+	h.respondErrorSpec(context, err, h.errorSpec(err))
+}
+
+// respondErrorSpec respond the error with the spec through the response adaptor
+func (h *Handler) respondErrorSpec(context *gin.Context, err error, spec ErrorSpec) {
+	if adaptor, ok := h.respAdaptor.(ErrorSpecAdaptor); ok {
+		adaptor.RespondErrorSpec(context, err, spec)
+		return
+	}
+	h.respAdaptor.RespondErrorResp(context, err, spec.Code)
+}
@@ -0,0 +1,668 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// the keywords of the filter expression
+const (
+	exprAnd   = "and"
+	exprOr    = "or"
+	exprNot   = "not"
+	exprField = "field"
+	exprOp    = "op"
+	exprValue = "value"
+)
+
+// filterOperators is all the operators of the comparison
+var filterOperators = []string{OpEq, OpNe, OpIn, OpGt, OpGte, OpLt, OpLte, OpContains}
+
+// FilterExpr is a node of the filter expression tree, which is one of *AndExpr, *OrExpr, *NotExpr and *CompareExpr.
+//
+// The filter is parsed from the JSON grammar:
+//
+//	{"and": [expr, ...]}
+//	{"or": [expr, ...]}
+//	{"not": expr}
+//	{"field": "amount", "op": "gte", "value": 10}
+//
+// An object which is none of the above is the flat format, such as {"asset": "BTM", "amount": {"gte": 10}},
+// whose keys are AND-ed. So "and", "or" and "not" can not be used as flat filter keys.
+type FilterExpr interface {
+	filterExpr()
+}
+
+// AndExpr match if all the sub expressions match, it matches everything if it is empty
+type AndExpr struct {
+	Exprs []FilterExpr
+}
+
+// OrExpr match if any of the sub expressions matches
+type OrExpr struct {
+	Exprs []FilterExpr
+}
+
+// NotExpr match if the sub expression does not match
+type NotExpr struct {
+	Expr FilterExpr
+}
+
+// CompareExpr compare the field with the value by the operator
+type CompareExpr struct {
+	Field string
+	// Column is the field name in storage
+	Column string
+	Op     string
+	Value  interface{}
+}
+
+func (*AndExpr) filterExpr()     {}
+func (*OrExpr) filterExpr()      {}
+func (*NotExpr) filterExpr()     {}
+func (*CompareExpr) filterExpr() {}
+
+// FilterVisitor visit the nodes of the filter expression, and produce a result of type R
+type FilterVisitor[R any] interface {
+	VisitAnd(e *AndExpr) R
+	VisitOr(e *OrExpr) R
+	VisitNot(e *NotExpr) R
+	VisitCompare(e *CompareExpr) R
+}
+
+// VisitFilter dispatch the expression to the visitor method of its node type
+func VisitFilter[R any](expr FilterExpr, v FilterVisitor[R]) R {
+	switch e := expr.(type) {
+	case *AndExpr:
+		return v.VisitAnd(e)
+	case *OrExpr:
+		return v.VisitOr(e)
+	case *NotExpr:
+		return v.VisitNot(e)
+	case *CompareExpr:
+		return v.VisitCompare(e)
+	}
+	panic(fmt.Sprintf("unknown filter expression %T", expr))
+}
+
+// ParseFilterExpr parse the filter into an expression without field whitelist, the malformed nodes are
+// returned as ValidationError
+func ParseFilterExpr(filter map[string]interface{}) (FilterExpr, error) {
+	return parseFilterExpr(filter, nil)
+}
+
+func parseFilterExpr(filter map[string]interface{}, schema *FilterSchema) (FilterExpr, error) {
+	p := &exprParser{schema: schema}
+	var expr FilterExpr = &AndExpr{}
+	if len(filter) != 0 {
+		expr = p.parse(filter, "filter")
+	}
+
+	if len(p.errs) != 0 {
+		return nil, &ValidationError{Fields: p.errs}
+	}
+	return expr, nil
+}
+
+// exprParser parse the filter expression, and validate the comparisons by the schema if it is not nil
+type exprParser struct {
+	schema *FilterSchema
+	errs   []FieldError
+}
+
+func (p *exprParser) fail(field, path, rule, param, message string) {
+	p.errs = append(p.errs, displayFieldError(field, path, rule, param, message))
+}
+
+func (p *exprParser) parse(raw interface{}, path string) FilterExpr {
+	node, ok := raw.(map[string]interface{})
+	if !ok {
+		p.fail("", path, "type", "object", path+" must be an object")
+		return nil
+	}
+
+	if len(node) == 1 {
+		if sub, ok := node[exprAnd]; ok {
+			return &AndExpr{Exprs: p.parseList(sub, path+"."+exprAnd)}
+		}
+		if sub, ok := node[exprOr]; ok {
+			return &OrExpr{Exprs: p.parseList(sub, path+"."+exprOr)}
+		}
+		if sub, ok := node[exprNot]; ok {
+			return &NotExpr{Expr: p.parse(sub, path+"."+exprNot)}
+		}
+	}
+
+	if isCompareNode(node) {
+		field, ok := node[exprField].(string)
+		if !ok || field == "" {
+			p.fail("", path+"."+exprField, "required", "", path+"."+exprField+" must be a non-empty string")
+			return nil
+		}
+
+		op, ok := node[exprOp].(string)
+		if !ok {
+			p.fail(field, path+"."+exprOp, "required", "", path+"."+exprOp+" must be a string")
+			return nil
+		}
+		return p.compare(field, op, node[exprValue], path+"."+exprField, path+"."+exprOp, path+"."+exprValue)
+	}
+	return p.parseFlat(node, path)
+}
+
+func (p *exprParser) parseList(raw interface{}, path string) []FilterExpr {
+	list, ok := raw.([]interface{})
+	if !ok || len(list) == 0 {
+		p.fail("", path, "type", "array", path+" must be a non-empty array")
+		return nil
+	}
+
+	exprs := make([]FilterExpr, len(list))
+	for i, item := range list {
+		exprs[i] = p.parse(item, fmt.Sprintf("%s[%d]", path, i))
+	}
+	return exprs
+}
+
+// parseFlat parse the flat format, the keys and operators are sorted to make the expression deterministic
+func (p *exprParser) parseFlat(node map[string]interface{}, path string) FilterExpr {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	and := &AndExpr{}
+	for _, key := range keys {
+		operands, ok := node[key].(map[string]interface{})
+		if !ok {
+			operands = map[string]interface{}{OpEq: node[key]}
+		}
+
+		ops := make([]string, 0, len(operands))
+		for op := range operands {
+			ops = append(ops, op)
+		}
+		sort.Strings(ops)
+
+		for _, op := range ops {
+			keyPath := path + "." + key
+			and.Exprs = append(and.Exprs, p.compare(key, op, operands[op], keyPath, keyPath+"."+op, keyPath+"."+op))
+		}
+	}
+
+	if len(and.Exprs) == 1 {
+		return and.Exprs[0]
+	}
+	return and
+}
+
+// compare validate and build the comparison, the paths locate the field, operator and value in the filter
+func (p *exprParser) compare(field, op string, value interface{}, fieldPath, opPath, valuePath string) FilterExpr {
+	if p.schema == nil {
+		if !containsString(filterOperators, op) {
+			p.fail(field, opPath, "operator", op, "unknown operator "+op)
+			return nil
+		}
+
+		value, err := normalizeOperand(op, value)
+		if err != nil {
+			p.fail(field, valuePath, "type", "", fmt.Sprintf("%s %v", valuePath, err))
+			return nil
+		}
+		return &CompareExpr{Field: field, Column: field, Op: op, Value: value}
+	}
+
+	schemaField, ok := p.schema.Fields[field]
+	if !ok {
+		p.fail(field, fieldPath, "whitelist", "", "filter "+field+" is not allowed")
+		return nil
+	}
+
+	if !schemaField.allows(op) {
+		p.fail(field, opPath, "operator", op, fmt.Sprintf("operator %s is not allowed on %s", op, field))
+		return nil
+	}
+
+	value, err := schemaField.coerce(op, value)
+	if err != nil {
+		p.fail(field, valuePath, "type", string(schemaField.Type), fmt.Sprintf("%s %v", valuePath, err))
+		return nil
+	}
+
+	column := schemaField.Column
+	if column == "" {
+		column = field
+	}
+	return &CompareExpr{Field: field, Column: column, Op: op, Value: value}
+}
+
+func isCompareNode(node map[string]interface{}) bool {
+	if _, ok := node[exprField]; !ok {
+		return false
+	}
+
+	for key := range node {
+		if key != exprField && key != exprOp && key != exprValue {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeOperand normalize the numbers of the operand when there is no schema to coerce it
+func normalizeOperand(op string, operand interface{}) (interface{}, error) {
+	if op == OpIn {
+		list, ok := operand.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("must be a non-empty array")
+		}
+
+		values := make([]interface{}, len(list))
+		for i, item := range list {
+			values[i] = normalizeScalar(item)
+		}
+		return values, nil
+	}
+
+	if op == OpContains {
+		if _, ok := operand.(string); !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+	}
+	return normalizeScalar(operand), nil
+}
+
+func normalizeScalar(operand interface{}) interface{} {
+	if val, ok := normalizeNumber(operand); ok {
+		return val
+	}
+	return operand
+}
+
+// bsonCompiler compile the filter expression to the mgo query
+type bsonCompiler struct{}
+
+func (c bsonCompiler) VisitAnd(e *AndExpr) bson.M {
+	query := bson.M{}
+	clauses := make([]interface{}, 0, len(e.Exprs))
+	merged := true
+	for _, sub := range e.Exprs {
+		clause := VisitFilter[bson.M](sub, c)
+		clauses = append(clauses, clause)
+		if merged {
+			merged = mergeBson(query, clause)
+		}
+	}
+
+	if merged {
+		return query
+	}
+	return bson.M{"$and": clauses}
+}
+
+func (c bsonCompiler) VisitOr(e *OrExpr) bson.M {
+	clauses := make([]interface{}, len(e.Exprs))
+	for i, sub := range e.Exprs {
+		clauses[i] = VisitFilter[bson.M](sub, c)
+	}
+	return bson.M{"$or": clauses}
+}
+
+func (c bsonCompiler) VisitNot(e *NotExpr) bson.M {
+	return bson.M{"$nor": []interface{}{VisitFilter[bson.M](e.Expr, c)}}
+}
+
+func (c bsonCompiler) VisitCompare(e *CompareExpr) bson.M {
+	if e.Op == OpContains {
+		return bson.M{e.Column: bson.M{"$regex": regexp.QuoteMeta(e.Value.(string))}}
+	}
+	return bson.M{e.Column: bson.M{"$" + e.Op: e.Value}}
+}
+
+// mergeBson merge the clause into the query for a compact AND, it returns false if they conflict
+func mergeBson(query, clause bson.M) bool {
+	for key, val := range clause {
+		existing, ok := query[key]
+		if !ok {
+			continue
+		}
+
+		existingOps, ok1 := existing.(bson.M)
+		ops, ok2 := val.(bson.M)
+		if strings.HasPrefix(key, "$") || !ok1 || !ok2 {
+			return false
+		}
+		for op := range ops {
+			if _, ok := existingOps[op]; ok {
+				return false
+			}
+		}
+	}
+
+	for key, val := range clause {
+		if existing, ok := query[key].(bson.M); ok {
+			for op, operand := range val.(bson.M) {
+				existing[op] = operand
+			}
+			continue
+		}
+
+		if ops, ok := val.(bson.M); ok {
+			copied := bson.M{}
+			for op, operand := range ops {
+				copied[op] = operand
+			}
+			val = copied
+		}
+		query[key] = val
+	}
+	return true
+}
+
+// sqlOperators is the SQL operators of the comparison operators
+var sqlOperators = map[string]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpGt:  ">",
+	OpGte: ">=",
+	OpLt:  "<",
+	OpLte: "<=",
+}
+
+func escapeLike(val string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(val)
+}
+
+// sqlClause is a compiled SQL condition with its placeholder args
+type sqlClause struct {
+	where string
+	args  []interface{}
+	// compound is whether the clause is joined by AND or OR, so it needs parentheses when nested
+	compound bool
+}
+
+// sqlCompiler compile the filter expression to the WHERE fragment with placeholders. An empty AND is the
+// tautology compiled to an empty clause, which is dropped from the AND and makes the OR a tautology too.
+type sqlCompiler struct{}
+
+const (
+	sqlAnd = " AND "
+	sqlOr  = " OR "
+	// sqlFalse is the contradiction negating a tautology
+	sqlFalse = "1 = 0"
+)
+
+func (c sqlCompiler) join(exprs []FilterExpr, sep string) sqlClause {
+	var (
+		wheres []string
+		args   []interface{}
+	)
+	for _, sub := range exprs {
+		clause := VisitFilter[sqlClause](sub, c)
+		if clause.where == "" {
+			if sep == sqlOr {
+				return sqlClause{}
+			}
+			continue
+		}
+
+		where := clause.where
+		if clause.compound {
+			where = "(" + where + ")"
+		}
+		wheres = append(wheres, where)
+		args = append(args, clause.args...)
+	}
+	return sqlClause{where: strings.Join(wheres, sep), args: args, compound: len(wheres) > 1}
+}
+
+func (c sqlCompiler) VisitAnd(e *AndExpr) sqlClause {
+	return c.join(e.Exprs, sqlAnd)
+}
+
+func (c sqlCompiler) VisitOr(e *OrExpr) sqlClause {
+	return c.join(e.Exprs, sqlOr)
+}
+
+func (c sqlCompiler) VisitNot(e *NotExpr) sqlClause {
+	clause := VisitFilter[sqlClause](e.Expr, c)
+	if clause.where == "" {
+		return sqlClause{where: sqlFalse}
+	}
+	return sqlClause{where: "NOT (" + clause.where + ")", args: clause.args}
+}
+
+func (c sqlCompiler) VisitCompare(e *CompareExpr) sqlClause {
+	switch e.Op {
+	case OpIn:
+		values := e.Value.([]interface{})
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		return sqlClause{where: fmt.Sprintf("%s IN (%s)", e.Column, placeholders), args: values}
+	case OpContains:
+		return sqlClause{where: fmt.Sprintf("%s LIKE ?", e.Column), args: []interface{}{"%" + escapeLike(e.Value.(string)) + "%"}}
+	default:
+		return sqlClause{where: fmt.Sprintf("%s %s ?", e.Column, sqlOperators[e.Op]), args: []interface{}{e.Value}}
+	}
+}
+
+// BsonFilter compile the filter expression to the mgo query
+func BsonFilter(expr FilterExpr) bson.M {
+	return VisitFilter[bson.M](expr, bsonCompiler{})
+}
+
+// SQLFilter compile the filter expression to the WHERE fragment and its placeholder args
+func SQLFilter(expr FilterExpr) (string, []interface{}) {
+	clause := VisitFilter[sqlClause](expr, sqlCompiler{})
+	return clause.where, clause.args
+}
+
+// MatchFilter evaluate the filter expression against the record in memory, the fields are looked up by name.
+// A missing field or a value of mismatched type only matches "ne".
+func MatchFilter(expr FilterExpr, record map[string]interface{}) bool {
+	return VisitFilter[bool](expr, filterEvaluator{record: record})
+}
+
+// filterEvaluator evaluate the filter expression against a record
+type filterEvaluator struct {
+	record map[string]interface{}
+}
+
+func (v filterEvaluator) VisitAnd(e *AndExpr) bool {
+	for _, sub := range e.Exprs {
+		if !VisitFilter[bool](sub, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (v filterEvaluator) VisitOr(e *OrExpr) bool {
+	for _, sub := range e.Exprs {
+		if VisitFilter[bool](sub, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v filterEvaluator) VisitNot(e *NotExpr) bool {
+	return !VisitFilter[bool](e.Expr, v)
+}
+
+func (v filterEvaluator) VisitCompare(e *CompareExpr) bool {
+	actual, ok := v.record[e.Field]
+	if !ok {
+		return e.Op == OpNe
+	}
+
+	switch e.Op {
+	case OpEq:
+		cmp, ok := compareValues(actual, e.Value)
+		return ok && cmp == 0
+	case OpNe:
+		cmp, ok := compareValues(actual, e.Value)
+		return !ok || cmp != 0
+	case OpIn:
+		for _, expected := range e.Value.([]interface{}) {
+			if cmp, ok := compareValues(actual, expected); ok && cmp == 0 {
+				return true
+			}
+		}
+		return false
+	case OpContains:
+		s, ok := actual.(string)
+		return ok && strings.Contains(s, e.Value.(string))
+	}
+
+	cmp, ok := compareValues(actual, e.Value)
+	if !ok {
+		return false
+	}
+
+	switch e.Op {
+	case OpGt:
+		return cmp > 0
+	case OpGte:
+		return cmp >= 0
+	case OpLt:
+		return cmp < 0
+	case OpLte:
+		return cmp <= 0
+	}
+	return false
+}
+
+// compareValues compare the actual value of the record with the expected value of the filter,
+// it returns false if they are not comparable
+func compareValues(actual, expected interface{}) (int, bool) {
+	switch exp := expected.(type) {
+	case string:
+		act, ok := actual.(string)
+		return strings.Compare(act, exp), ok
+
+	case bool:
+		act, ok := actual.(bool)
+		if !ok {
+			return 0, false
+		}
+		if act == exp {
+			return 0, true
+		}
+		if exp {
+			return -1, true
+		}
+		return 1, true
+
+	case time.Time:
+		var act time.Time
+		switch val := actual.(type) {
+		case time.Time:
+			act = val
+		case string:
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				return 0, false
+			}
+			act = t
+		default:
+			return 0, false
+		}
+
+		switch {
+		case act.Before(exp):
+			return -1, true
+		case act.After(exp):
+			return 1, true
+		}
+		return 0, true
+	}
+
+	exp, ok := toRat(expected)
+	if !ok {
+		return 0, false
+	}
+
+	act, ok := toRat(actual)
+	if !ok {
+		return 0, false
+	}
+	return act.Cmp(exp), true
+}
+
+// toRat convert the number to the exact rational, so that the large integers are compared without precision loss
+func toRat(val interface{}) (*big.Rat, bool) {
+	switch v := val.(type) {
+	case json.Number:
+		return new(big.Rat).SetString(v.String())
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, false
+		}
+		return new(big.Rat).SetFloat64(v), true
+	case float32:
+		return toRat(float64(v))
+	case int:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(v)), true
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Rat).SetUint64(v), true
+	}
+	return nil, false
+}
+
+func toFloat64(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
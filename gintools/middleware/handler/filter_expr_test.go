@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func parseFilter(t *testing.T, s string) map[string]interface{} {
+	var filter map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &filter); err != nil {
+		t.Fatal(err)
+	}
+	return filter
+}
+
+const nestedFilter = `{"and":[
+	{"field":"amount","op":"gte","value":10},
+	{"or":[{"field":"asset","op":"eq","value":"BTM"},{"not":{"status":{"in":["failed","pending"]}}}]}
+]}`
+
+func TestFilterExprCompile(t *testing.T) {
+	expr, err := testSchema.Expr(&Display{Filter: parseFilter(t, nestedFilter)})
+	if err != nil {
+		t.Fatalf("Expr() error = %v", err)
+	}
+
+	wantBson := bson.M{
+		"amount": bson.M{"$gte": int64(10)},
+		"$or": []interface{}{
+			bson.M{"asset_id": bson.M{"$eq": "BTM"}},
+			bson.M{"$nor": []interface{}{bson.M{"status": bson.M{"$in": []interface{}{"failed", "pending"}}}}},
+		},
+	}
+	if query := BsonFilter(expr); !reflect.DeepEqual(query, wantBson) {
+		t.Errorf("BsonFilter() = %v, want %v", query, wantBson)
+	}
+
+	where, args := SQLFilter(expr)
+	if want := "amount >= ? AND (asset_id = ? OR NOT (status IN (?, ?)))"; where != want {
+		t.Errorf("SQLFilter() where = %q, want %q", where, want)
+	}
+	if wantArgs := []interface{}{int64(10), "BTM", "failed", "pending"}; !reflect.DeepEqual(args, wantArgs) {
+		t.Errorf("SQLFilter() args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestFilterExprEmptyObject(t *testing.T) {
+	tests := []struct {
+		filter    string
+		wantBson  bson.M
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			filter:    `{"not":{}}`,
+			wantBson:  bson.M{"$nor": []interface{}{bson.M{}}},
+			wantWhere: "1 = 0",
+		},
+		{
+			filter:    `{"and":[{},{"amount":1}]}`,
+			wantBson:  bson.M{"amount": bson.M{"$eq": int64(1)}},
+			wantWhere: "amount = ?",
+			wantArgs:  []interface{}{int64(1)},
+		},
+		{
+			filter:    `{"and":[{"amount":1},{"or":[{"and":[{}]},{"a":2}]}]}`,
+			wantBson:  bson.M{"amount": bson.M{"$eq": int64(1)}, "$or": []interface{}{bson.M{}, bson.M{"a": bson.M{"$eq": int64(2)}}}},
+			wantWhere: "amount = ?",
+			wantArgs:  []interface{}{int64(1)},
+		},
+		{
+			filter:    `{"or":[{"not":{}},{"a":2}]}`,
+			wantBson:  bson.M{"$or": []interface{}{bson.M{"$nor": []interface{}{bson.M{}}}, bson.M{"a": bson.M{"$eq": int64(2)}}}},
+			wantWhere: "1 = 0 OR a = ?",
+			wantArgs:  []interface{}{int64(2)},
+		},
+	}
+	for _, tt := range tests {
+		expr, err := ParseFilterExpr(parseFilter(t, tt.filter))
+		if err != nil {
+			t.Fatalf("ParseFilterExpr(%s) error = %v", tt.filter, err)
+		}
+
+		if query := BsonFilter(expr); !reflect.DeepEqual(query, tt.wantBson) {
+			t.Errorf("BsonFilter(%s) = %v, want %v", tt.filter, query, tt.wantBson)
+		}
+
+		where, args := SQLFilter(expr)
+		if where != tt.wantWhere || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("SQLFilter(%s) = %q %v, want %q %v", tt.filter, where, args, tt.wantWhere, tt.wantArgs)
+		}
+	}
+}
+
+func TestBsonFilterConflict(t *testing.T) {
+	expr, err := ParseFilterExpr(parseFilter(t, `{"and":[{"field":"a","op":"gt","value":1},{"field":"a","op":"gt","value":2}]}`))
+	if err != nil {
+		t.Fatalf("ParseFilterExpr() error = %v", err)
+	}
+
+	want := bson.M{"$and": []interface{}{bson.M{"a": bson.M{"$gt": int64(1)}}, bson.M{"a": bson.M{"$gt": int64(2)}}}}
+	if query := BsonFilter(expr); !reflect.DeepEqual(query, want) {
+		t.Errorf("BsonFilter() = %v, want %v", query, want)
+	}
+}
+
+func TestMatchFilter(t *testing.T) {
+	expr, err := ParseFilterExpr(parseFilter(t, `{"or":[
+		{"and":[{"field":"amount","op":"gte","value":10},{"field":"memo","op":"contains","value":"pay"}]},
+		{"not":{"field":"status","op":"ne","value":"vip"}}
+	]}`))
+	if err != nil {
+		t.Fatalf("ParseFilterExpr() error = %v", err)
+	}
+
+	cases := []struct {
+		record map[string]interface{}
+		want   bool
+	}{
+		{record: map[string]interface{}{"amount": uint64(12), "memo": "payment"}, want: true},
+		{record: map[string]interface{}{"amount": 9.5, "memo": "payment"}, want: false},
+		{record: map[string]interface{}{"amount": 12, "memo": "refund"}, want: false},
+		{record: map[string]interface{}{"amount": "12", "memo": "payment"}, want: false},
+		{record: map[string]interface{}{"status": "vip"}, want: true},
+		{record: map[string]interface{}{}, want: false},
+	}
+
+	for i, c := range cases {
+		if got := MatchFilter(expr, c.record); got != c.want {
+			t.Errorf("case %d: MatchFilter() = %v, want %v", i, got, c.want)
+		}
+	}
+}
+
+func TestParseFilterExprError(t *testing.T) {
+	cases := []struct {
+		filter string
+		paths  []string
+	}{
+		{filter: `{"and":[]}`, paths: []string{"filter.and"}},
+		{filter: `{"or":[1]}`, paths: []string{"filter.or[0]"}},
+		{filter: `{"not":{"field":"a","op":"like","value":"x"}}`, paths: []string{"filter.not.op"}},
+		{filter: `{"and":[{"field":"","op":"eq"},{"field":"a","op":"in","value":1}]}`, paths: []string{"filter.and[0].field", "filter.and[1].value"}},
+	}
+
+	for i, c := range cases {
+		_, err := ParseFilterExpr(parseFilter(t, c.filter))
+		validationErr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("case %d: error = %v, want *ValidationError", i, err)
+			continue
+		}
+
+		var paths []string
+		for _, field := range validationErr.Fields {
+			paths = append(paths, field.Path)
+		}
+		if !reflect.DeepEqual(paths, c.paths) {
+			t.Errorf("case %d: paths = %v, want %v", i, paths, c.paths)
+		}
+	}
+
+	_, err := testSchema.Expr(&Display{Filter: parseFilter(t, `{"or":[{"field":"memo","op":"eq","value":"x"},{"field":"amount","op":"contains","value":"1"}]}`)})
+	validationErr, ok := err.(*ValidationError)
+	if !ok || len(validationErr.Fields) != 2 || validationErr.Fields[0].Rule != "whitelist" || validationErr.Fields[1].Path != "filter.or[1].op" {
+		t.Errorf("Expr() error = %v, want the whitelist and operator errors", err)
+	}
+}
+
+func TestMatchFilterLargeAmount(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"field":"amount","op":"gt","value":18446744073709551614}`))
+	decoder.UseNumber()
+	var filter map[string]interface{}
+	if err := decoder.Decode(&filter); err != nil {
+		t.Fatal(err)
+	}
+
+	expr, err := ParseFilterExpr(filter)
+	if err != nil {
+		t.Fatalf("ParseFilterExpr() error = %v", err)
+	}
+	if !MatchFilter(expr, map[string]interface{}{"amount": uint64(18446744073709551615)}) {
+		t.Error("MatchFilter() = false, want the amounts above 2^53 compared exactly")
+	}
+	if MatchFilter(expr, map[string]interface{}{"amount": uint64(18446744073709551614)}) {
+		t.Error("MatchFilter() = true, want the equal amount not greater")
+	}
+}
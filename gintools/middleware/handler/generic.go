@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// Empty represent a request without arguments, the request body of it will not be bound
+type Empty struct{}
+
+// JSON wrap a typed handler function, and return a gin-compatible processing functions.
+// Unlike HandleMiddleware, the signature is checked at compile time and no reflection is used per request.
+func JSON[Req any, Resp any](h *Handler, fun func(*gin.Context, *Req) (Resp, error), opts ...RouteOption) func(*gin.Context) {
+	cfg := h.routeConfig(opts...)
+	return func(context *gin.Context) {
+		defer h.recoverPanic(context)
+		if ok := h.applyFrontFilters(context); !ok {
+			return
+		}
+
+		req, err := bindTypedReqArg[Req](h, context)
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+
+		resp, err := runHandleFunc(context, cfg.timeout, func(c *gin.Context) (Resp, error) {
+			return fun(c, req)
+		})
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+
+		h.respAdaptor.RespondSuccessResp(context, resp)
+	}
+}
+
+// Paginated wrap a typed pagination handler function, which return the data of current page and the total count
+func Paginated[Req any, T any](h *Handler, fun func(*gin.Context, *Req, *PaginationQuery) ([]T, uint64, error), opts ...RouteOption) func(*gin.Context) {
+	cfg := h.routeConfig(opts...)
+	return func(context *gin.Context) {
+		defer h.recoverPanic(context)
+		if ok := h.applyFrontFilters(context); !ok {
+			return
+		}
+
+		req, err := bindTypedReqArg[Req](h, context)
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+
+		query, err := ParsePaginationWithOptions(context, cfg.pagination)
+		if err != nil {
+			h.respondError(context, errors.Wrap(err, "ParsePagination"))
+			return
+		}
+
+		page, err := runHandleFunc(context, cfg.timeout, func(c *gin.Context) (*PaginationResult, error) {
+			list, total, err := fun(c, req, query)
+			return NewPaginationResult(list, total), err
+		})
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+
+		h.respAdaptor.RespondSuccessPaginationResp(context, page.data, NewPaginationProcessor(query, page.total))
+	}
+}
+
+// CursorPaginated wrap a typed cursor pagination handler function, which return the data of current page,
+// the cursor of the last item if there are more items, and the cursor of the first item if it is not the first page
+func CursorPaginated[Req any, T any](h *Handler, fun func(*gin.Context, *Req, *CursorQuery) ([]T, Cursor, Cursor, error), opts ...RouteOption) func(*gin.Context) {
+	cfg := h.routeConfig(opts...)
+	return func(context *gin.Context) {
+		defer h.recoverPanic(context)
+		if ok := h.applyFrontFilters(context); !ok {
+			return
+		}
+
+		req, err := bindTypedReqArg[Req](h, context)
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+
+		query, err := ParseCursorWithOptions(context, h.cursorCodec, cfg.pagination)
+		if err != nil {
+			h.respondError(context, errors.Wrap(err, "ParseCursor"))
+			return
+		}
+
+		result, err := runHandleFunc(context, cfg.timeout, func(c *gin.Context) (*CursorResult, error) {
+			list, next, prev, err := fun(c, req, query)
+			return NewCursorResult(list, next, prev), err
+		})
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+
+		h.respondCursorPagination(context, query, result)
+	}
+}
+
+// bindTypedReqArg create the request argument of type Req, and pass it through the request filters
+func bindTypedReqArg[Req any](h *Handler, context *gin.Context) (*Req, error) {
+	req := new(Req)
+	var filterArg interface{}
+	if _, ok := interface{}(req).(*Empty); !ok {
+		if err := bindReqArg(context, req); err != nil {
+			return nil, errors.Wrap(err, "createHandleReqArg")
+		}
+		filterArg = req
+	}
+
+	if err := h.applyRequestFilters(context, filterArg); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
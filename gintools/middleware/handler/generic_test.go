@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type echoReq struct {
+	Name string `json:"name"`
+}
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func serve(method, target, body string, fun func(*gin.Context)) Response {
+	engine := gin.New()
+	engine.Handle(method, strings.Split(target, "?")[0], fun)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+
+	var resp Response
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return resp
+}
+
+func TestJSON(t *testing.T) {
+	errNotFound := errors.New("not found")
+	h := NewHandler(map[error]int{errNotFound: 404}, nil, nil)
+
+	fun := JSON(h, func(c *gin.Context, req *echoReq) (string, error) {
+		if req.Name == "" {
+			return "", errNotFound
+		}
+		return "hello " + req.Name, nil
+	})
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantData interface{}
+	}{
+		{name: "success", body: `{"name":"bytom"}`, wantCode: 200, wantData: "hello bytom"},
+		{name: "mapped error", body: `{}`, wantCode: 404},
+		{name: "bind error", body: `{`, wantCode: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := serve(http.MethodPost, "/echo", tt.body, fun)
+			if resp.Code != tt.wantCode {
+				t.Errorf("code = %d, want %d", resp.Code, tt.wantCode)
+			}
+			if resp.Data != tt.wantData {
+				t.Errorf("data = %v, want %v", resp.Data, tt.wantData)
+			}
+		})
+	}
+}
+
+func TestPaginated(t *testing.T) {
+	h := NewHandler(nil, nil, nil)
+	fun := Paginated(h, func(c *gin.Context, req *Empty, query *PaginationQuery) ([]uint64, uint64, error) {
+		return []uint64{query.Start, query.Limit}, 100, nil
+	})
+
+	resp := serve(http.MethodGet, "/list?start=20&limit=5", "", fun)
+	if resp.Code != 200 {
+		t.Fatalf("code = %d, want 200", resp.Code)
+	}
+	if resp.Pagination == nil || resp.Pagination.Total != 100 || resp.Pagination.Start != 20 {
+		t.Fatalf("pagination = %+v, want start 20 total 100", resp.Pagination)
+	}
+	if resp.Pagination.Links.Next != "/list?limit=5&start=25" {
+		t.Errorf("next link = %s", resp.Pagination.Links.Next)
+	}
+}
+
+func TestStatusResponse(t *testing.T) {
+	errNotFound := errors.New("not found")
+	errUnknown := errors.New("unknown")
+	h := NewHandlerWithErrorSpecs(map[error]ErrorSpec{
+		errNotFound: {HTTPStatus: http.StatusNotFound, Code: 404, Message: "resource not found"},
+	}, nil, nil).SetResponseAdaptor(&StatusResponse{})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   int
+	}{
+		{name: "mapped error", err: errNotFound, wantStatus: http.StatusNotFound, wantCode: 404},
+		{name: "unmapped error", err: errUnknown, wantStatus: http.StatusInternalServerError, wantCode: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/", h.HandleMiddleware(func(c *gin.Context) error { return tt.err }))
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			var resp Response
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if w.Code != tt.wantStatus || resp.Code != tt.wantCode {
+				t.Errorf("status = %d code = %d, want %d %d", w.Code, resp.Code, tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPaginationOptions(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetValidationErrCode(400).SetPaginationOptions(PaginationOptions{
+		DefaultLimit:   20,
+		MaxLimit:       50,
+		RejectOversize: true,
+		Style:          PageStyle,
+	})
+	list := func(c *gin.Context, req *Empty, query *PaginationQuery) ([]uint64, uint64, error) {
+		return []uint64{query.Start, query.Limit}, 100, nil
+	}
+
+	resp := serve(http.MethodGet, "/list?page=3&page_size=10&asset=btm", "", Paginated(h, list))
+	if resp.Code != 200 || resp.Pagination.Start != 20 {
+		t.Fatalf("code = %d pagination = %+v, want start 20", resp.Code, resp.Pagination)
+	}
+	if resp.Pagination.Links.Next != "/list?asset=btm&page=4&page_size=10" || resp.Pagination.Links.Last != "/list?asset=btm&page=10&page_size=10" {
+		t.Errorf("links = %+v", resp.Pagination.Links)
+	}
+
+	resp = serve(http.MethodGet, "/list?page_size=100", "", Paginated(h, list))
+	if resp.Code != 400 || len(resp.Errors) != 1 || resp.Errors[0].Rule != "max" {
+		t.Errorf("code = %d errors = %+v, want the oversize limit rejected", resp.Code, resp.Errors)
+	}
+
+	resp = serve(http.MethodGet, "/list?limit=100", "", Paginated(h, list, WithPagination(DefaultPaginationOptions)))
+	if resp.Code != 200 || resp.Pagination.Limit != 100 {
+		t.Errorf("code = %d pagination = %+v, want the route options override", resp.Code, resp.Pagination)
+	}
+}
+
+func TestPartialPaginationOptions(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetValidationErrCode(400)
+	list := func(c *gin.Context, req *Empty, query *PaginationQuery) ([]uint64, uint64, error) {
+		return []uint64{query.Start, query.Limit}, 100, nil
+	}
+	partial := WithPagination(PaginationOptions{Style: PageStyle})
+
+	resp := serve(http.MethodGet, "/list", "", Paginated(h, list, partial))
+	if resp.Code != 200 || resp.Pagination.Limit != DefaultPaginationOptions.DefaultLimit {
+		t.Errorf("code = %d pagination = %+v, want the default limit", resp.Code, resp.Pagination)
+	}
+
+	resp = serve(http.MethodGet, "/list?page_size=100000", "", Paginated(h, list, partial))
+	if resp.Code != 200 || resp.Pagination.Limit != DefaultPaginationOptions.MaxLimit {
+		t.Errorf("code = %d pagination = %+v, want the limit clamped to the default max", resp.Code, resp.Pagination)
+	}
+
+	resp = serve(http.MethodGet, "/list?page=18446744073709551615&page_size=10", "", Paginated(h, list, partial))
+	if resp.Code != 400 || len(resp.Errors) != 1 || resp.Errors[0].Path != "page" || resp.Errors[0].Rule != "max" {
+		t.Errorf("code = %d errors = %+v, want the overflowing page rejected", resp.Code, resp.Errors)
+	}
+
+	resp = serve(http.MethodGet, "/list?page=1844674407370955162&page_size=10", "", Paginated(h, list, partial))
+	if resp.Code != 400 || len(resp.Errors) != 1 || resp.Errors[0].Param != "1844674407370955161" {
+		t.Errorf("code = %d errors = %+v, want the page whose next page overflows rejected", resp.Code, resp.Errors)
+	}
+
+	resp = serve(http.MethodGet, "/list?start=18446744073709551610&limit=10", "", Paginated(h, list))
+	if resp.Code != 400 || len(resp.Errors) != 1 || resp.Errors[0].Path != "start" || resp.Errors[0].Param != "18446744073709551605" {
+		t.Errorf("code = %d errors = %+v, want the overflowing start rejected", resp.Code, resp.Errors)
+	}
+}
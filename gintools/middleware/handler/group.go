@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandlerOption extend the configuration of a derived handler
+type HandlerOption func(*Handler)
+
+// WithFrontFilters append the front filters, which run after the inherited ones
+func WithFrontFilters(filters ...FrontFilter) HandlerOption {
+	return func(h *Handler) {
+		h.frontFilters = append(h.frontFilters, filters...)
+	}
+}
+
+// WithRequestFilters append the request filters, which run after the inherited ones
+func WithRequestFilters(filters ...RequestFilter) HandlerOption {
+	return func(h *Handler) {
+		h.requestFilters = append(h.requestFilters, filters...)
+	}
+}
+
+// WithErrorCodes register the error codes, which override the inherited ones of the same errors
+func WithErrorCodes(errorCodes map[error]int) HandlerOption {
+	return WithErrorSpecs(errorSpecsFromCodes(errorCodes))
+}
+
+// WithErrorSpecs register the error specs, which override the inherited ones of the same errors.
+// They are registered in the order of the error code and the error text like NewErrorRegistryFromSpecs.
+func WithErrorSpecs(errorSpecs map[error]ErrorSpec) HandlerOption {
+	return func(h *Handler) {
+		h.errorRegistry.registerSpecs(errorSpecs)
+	}
+}
+
+// WithResponseAdaptor replace the response adaptor
+func WithResponseAdaptor(respAdaptor ResponseAdaptor) HandlerOption {
+	return func(h *Handler) {
+		h.respAdaptor = respAdaptor
+	}
+}
+
+// WithRouteOptions append the default route options, which apply to every route before its own options
+func WithRouteOptions(opts ...RouteOption) HandlerOption {
+	return func(h *Handler) {
+		h.routeOptions = append(h.routeOptions, opts...)
+	}
+}
+
+// With derive a child handler, which inherits the filters, error specs, response adaptor and the other
+// configuration of the handler, and is extended by the options. Changing the child never affects the parent.
+func (h *Handler) With(opts ...HandlerOption) *Handler {
+	child := *h
+	child.frontFilters = append([]FrontFilter(nil), h.frontFilters...)
+	child.requestFilters = append([]RequestFilter(nil), h.requestFilters...)
+	child.routeOptions = append([]RouteOption(nil), h.routeOptions...)
+	child.errorRegistry = h.errorRegistry.clone()
+	for _, opt := range opts {
+		opt(&child)
+	}
+	return &child
+}
+
+// RouteGroup register the handler functions of a derived handler on a gin router group,
+// and record the routes in the route registry of the handler
+type RouteGroup struct {
+	*Handler
+	Router *gin.RouterGroup
+}
+
+// Group derive a child handler by the options, and return the route group of it under the relative path
+func (h *Handler) Group(router gin.IRouter, relativePath string, opts ...HandlerOption) *RouteGroup {
+	return &RouteGroup{Handler: h.With(opts...), Router: router.Group(relativePath)}
+}
+
+// Group return the nested route group, whose handler is derived from the handler of this group
+func (g *RouteGroup) Group(relativePath string, opts ...HandlerOption) *RouteGroup {
+	return g.Handler.Group(g.Router, relativePath, opts...)
+}
+
+// Handle register the handler function wrapped by HandleMiddleware with the method and the relative path
+func (g *RouteGroup) Handle(method, relativePath string, handleFunc interface{}, opts ...RouteOption) *RouteGroup {
+	if err := ValidateFuncType(handleFunc); err != nil {
+		panic(err)
+	}
+
+	req, resp, pagination := describeFunc(handleFunc)
+	return g.handle(method, relativePath, g.HandleMiddleware(handleFunc, opts...), req, resp, pagination, opts)
+}
+
+// handle register the processing function to the router and the route registry
+func (g *RouteGroup) handle(method, relativePath string, handle gin.HandlerFunc, req, resp reflect.Type, pagination string, opts []RouteOption) *RouteGroup {
+	g.Router.Handle(method, relativePath, handle)
+	g.routes.add(g.describeRoute(method, joinPaths(g.Router.BasePath(), relativePath), req, resp, pagination, g.Router.Handlers, handle, opts))
+	return g
+}
+
+// GET register the handler function of GET requests
+func (g *RouteGroup) GET(relativePath string, handleFunc interface{}, opts ...RouteOption) *RouteGroup {
+	return g.Handle(http.MethodGet, relativePath, handleFunc, opts...)
+}
+
+// POST register the handler function of POST requests
+func (g *RouteGroup) POST(relativePath string, handleFunc interface{}, opts ...RouteOption) *RouteGroup {
+	return g.Handle(http.MethodPost, relativePath, handleFunc, opts...)
+}
+
+// PUT register the handler function of PUT requests
+func (g *RouteGroup) PUT(relativePath string, handleFunc interface{}, opts ...RouteOption) *RouteGroup {
+	return g.Handle(http.MethodPut, relativePath, handleFunc, opts...)
+}
+
+// PATCH register the handler function of PATCH requests
+func (g *RouteGroup) PATCH(relativePath string, handleFunc interface{}, opts ...RouteOption) *RouteGroup {
+	return g.Handle(http.MethodPatch, relativePath, handleFunc, opts...)
+}
+
+// DELETE register the handler function of DELETE requests
+func (g *RouteGroup) DELETE(relativePath string, handleFunc interface{}, opts ...RouteOption) *RouteGroup {
+	return g.Handle(http.MethodDelete, relativePath, handleFunc, opts...)
+}
+
+// HandleJSON register the typed handler function wrapped by JSON with the method and the relative path
+func HandleJSON[Req any, Resp any](g *RouteGroup, method, relativePath string, fun func(*gin.Context, *Req) (Resp, error), opts ...RouteOption) *RouteGroup {
+	return g.handle(method, relativePath, JSON(g.Handler, fun, opts...), reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf((*Resp)(nil)).Elem(), "", opts)
+}
+
+// HandlePaginated register the typed pagination handler function wrapped by Paginated with the method and the relative path
+func HandlePaginated[Req any, T any](g *RouteGroup, method, relativePath string, fun func(*gin.Context, *Req, *PaginationQuery) ([]T, uint64, error), opts ...RouteOption) *RouteGroup {
+	return g.handle(method, relativePath, Paginated(g.Handler, fun, opts...), reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf([]T(nil)), PaginationOffset, opts)
+}
+
+// HandleCursorPaginated register the typed cursor pagination handler function wrapped by CursorPaginated with the method and the relative path
+func HandleCursorPaginated[Req any, T any](g *RouteGroup, method, relativePath string, fun func(*gin.Context, *Req, *CursorQuery) ([]T, Cursor, Cursor, error), opts ...RouteOption) *RouteGroup {
+	return g.handle(method, relativePath, CursorPaginated(g.Handler, fun, opts...), reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf([]T(nil)), PaginationCursor, opts)
+}
+
+// HandleStream register the typed streaming handler function wrapped by Stream with the method and the relative path
+func HandleStream[Req any, T any](g *RouteGroup, method, relativePath string, fun func(*gin.Context, *Req) (<-chan T, error), opts ...RouteOption) *RouteGroup {
+	return g.handle(method, relativePath, Stream(g.Handler, fun, opts...), reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf((<-chan T)(nil)), "", opts)
+}
+
+// HandleStreamSeq register the typed streaming handler function wrapped by StreamSeq with the method and the relative path
+func HandleStreamSeq[Req any, T any](g *RouteGroup, method, relativePath string, fun func(*gin.Context, *Req) (func(yield func(T) bool), error), opts ...RouteOption) *RouteGroup {
+	return g.handle(method, relativePath, StreamSeq(g.Handler, fun, opts...), reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf((func(yield func(T) bool))(nil)), "", opts)
+}
+
+// HandleStreamSeq2 register the typed streaming handler function wrapped by StreamSeq2 with the method and the relative path
+func HandleStreamSeq2[Req any, T any](g *RouteGroup, method, relativePath string, fun func(*gin.Context, *Req) (func(yield func(T, error) bool), error), opts ...RouteOption) *RouteGroup {
+	return g.handle(method, relativePath, StreamSeq2(g.Handler, fun, opts...), reflect.TypeOf((*Req)(nil)).Elem(), reflect.TypeOf((func(yield func(T, error) bool))(nil)), "", opts)
+}
+
+// joinPaths join the relative path to the base path, and keep the trailing slash of the relative path as gin does
+func joinPaths(basePath, relativePath string) string {
+	if relativePath == "" {
+		return basePath
+	}
+
+	joined := path.Join(basePath, relativePath)
+	if relativePath[len(relativePath)-1] == '/' && joined[len(joined)-1] != '/' {
+		return joined + "/"
+	}
+	return joined
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRouteGroup(t *testing.T) {
+	errNotFound := errors.New("not found")
+	errForbidden := errors.New("forbidden")
+
+	var trace []string
+	tracer := func(name string) FrontFilter {
+		return func(c *gin.Context) error {
+			trace = append(trace, name)
+			return nil
+		}
+	}
+
+	root := NewHandler(map[error]int{errNotFound: 40400}, []FrontFilter{tracer("root")}, nil)
+	engine := gin.New()
+	admin := root.Group(engine, "/admin", WithFrontFilters(tracer("admin")), WithErrorCodes(map[error]int{errNotFound: 40401, errForbidden: 40300}))
+	admin.GET("/users", func(c *gin.Context) (string, error) { return "", errNotFound }).
+		DELETE("/users", func(c *gin.Context) (string, error) { return "", errForbidden })
+	v2 := admin.Group("/v2", WithResponseAdaptor(&StatusResponse{}), WithFrontFilters(tracer("v2")))
+	v2.GET("/users", func(c *gin.Context) (string, error) { return "", errNotFound })
+	engine.GET("/users", root.HandleMiddleware(func(c *gin.Context) (string, error) { return "", errNotFound }))
+
+	cases := []struct {
+		method string
+		path   string
+		status int
+		code   string
+		trace  []string
+	}{
+		{method: http.MethodGet, path: "/admin/users", status: http.StatusOK, code: `"code":40401`, trace: []string{"root", "admin"}},
+		{method: http.MethodDelete, path: "/admin/users", status: http.StatusOK, code: `"code":40300`, trace: []string{"root", "admin"}},
+		{method: http.MethodGet, path: "/admin/v2/users", status: http.StatusBadRequest, code: `"code":40401`, trace: []string{"root", "admin", "v2"}},
+		{method: http.MethodGet, path: "/users", status: http.StatusOK, code: `"code":40400`, trace: []string{"root"}},
+	}
+
+	for _, c := range cases {
+		trace = nil
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code != c.status || !strings.Contains(w.Body.String(), c.code) {
+			t.Errorf("%s %s = %d %s, want %d with %s", c.method, c.path, w.Code, w.Body.String(), c.status, c.code)
+		}
+		if strings.Join(trace, ",") != strings.Join(c.trace, ",") {
+			t.Errorf("%s %s front filters = %v, want %v", c.method, c.path, trace, c.trace)
+		}
+	}
+
+	if _, ok := root.ErrorRegistry().Resolve(errForbidden); ok {
+		t.Error("the error code registered to the group leaks to the parent handler")
+	}
+}
+
+func TestRouteGroupInvalidFunc(t *testing.T) {
+	g := NewHandler(nil, nil, nil).Group(gin.New(), "/")
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !strings.Contains(err.Error(), "need nonvariadic func") {
+			t.Errorf("recovered = %v, want the validation error of the handler function", err)
+		}
+	}()
+	g.GET("/bad", "not a function")
+}
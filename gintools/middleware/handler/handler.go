@@ -3,6 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"reflect"
+	"time"
 
 	"github.com/bytom/bytom/errors"
 	"github.com/gin-gonic/gin"
@@ -18,27 +19,86 @@ type RequestFilter func(ctx *gin.Context, req interface{}) error
 type Handler struct {
 	frontFilters   []FrontFilter
 	requestFilters []RequestFilter
-	errorCodes     map[error]int
+	errorRegistry  *ErrorRegistry
 	respAdaptor    ResponseAdaptor
+	cursorCodec    *CursorCodec
+	pagination     PaginationOptions
+	timeout        time.Duration
+	streamOptions  StreamOptions
+	routeOptions   []RouteOption
+	routes         *RouteRegistry
+
+	validationErrCode int
+	panicErrCode      int
+	timeoutErrCode    int
 }
 
 type handlerFun interface{}
 
 // NewHandler return a handler instance
 func NewHandler(errorCodes map[error]int, frontFilters []FrontFilter, requestFilters []RequestFilter) *Handler {
+	return NewHandlerWithErrorSpecs(errorSpecsFromCodes(errorCodes), frontFilters, requestFilters)
+}
+
+// NewHandlerWithErrorSpecs return a handler instance whose errors are mapped to ErrorSpec with the HTTP status
+func NewHandlerWithErrorSpecs(errorSpecs map[error]ErrorSpec, frontFilters []FrontFilter, requestFilters []RequestFilter) *Handler {
 	return &Handler{
 		frontFilters:   frontFilters,
 		requestFilters: requestFilters,
-		errorCodes:     errorCodes,
+		errorRegistry:  NewErrorRegistryFromSpecs(errorSpecs),
 		respAdaptor:    &StandardResponse{},
+		cursorCodec:    &CursorCodec{},
+		pagination:     DefaultPaginationOptions,
+		streamOptions:  DefaultStreamOptions,
+		routes:         NewRouteRegistry(),
 	}
 }
 
+// Routes return the route registry which records the routes registered by the route groups of the handler
+func (h *Handler) Routes() *RouteRegistry {
+	return h.routes
+}
+
+// SetRouteRegistry replace the route registry of the handler
+func (h *Handler) SetRouteRegistry(routes *RouteRegistry) *Handler {
+	h.routes = routes
+	return h
+}
+
+// ErrorRegistry return the error registry of the handler, which can be used to register more error specs
+func (h *Handler) ErrorRegistry() *ErrorRegistry {
+	return h.errorRegistry
+}
+
+// SetErrorRegistry replace the error registry of the handler
+func (h *Handler) SetErrorRegistry(errorRegistry *ErrorRegistry) *Handler {
+	h.errorRegistry = errorRegistry
+	return h
+}
+
 func (h *Handler) SetResponseAdaptor(respAdaptor ResponseAdaptor) *Handler {
 	h.respAdaptor = respAdaptor
 	return h
 }
 
+// SetCursorCodec set the codec of the cursor pagination, set a codec with secret to accept the cursors across processes
+func (h *Handler) SetCursorCodec(cursorCodec *CursorCodec) *Handler {
+	h.cursorCodec = cursorCodec
+	return h
+}
+
+// SetPaginationOptions set the default pagination options of the routes
+func (h *Handler) SetPaginationOptions(opts PaginationOptions) *Handler {
+	h.pagination = opts.withDefaults()
+	return h
+}
+
+// SetValidationErrCode set the error code responded when the request argument is failed to bind or validate
+func (h *Handler) SetValidationErrCode(code int) *Handler {
+	h.validationErrCode = code
+	return h
+}
+
 func callHandleFunc(fun handlerFun, args ...interface{}) []interface{} {
 	fv := reflect.ValueOf(fun)
 
@@ -61,54 +121,105 @@ func createHandleReqArg(fun handlerFun, context *gin.Context) (interface{}, erro
 		return nil, nil
 	}
 
-	if ft.In(1) == paginationQueryType {
+	if ft.In(1) == paginationQueryType || ft.In(1) == cursorQueryType {
 		return nil, nil
 	}
 
 	argType := ft.In(1).Elem()
 
 	reqArg := reflect.New(argType).Interface()
-	if err := context.ShouldBindJSON(reqArg); err != nil {
-		return nil, errors.Wrap(err, "bind reqArg")
+	if err := bindReqArg(context, reqArg); err != nil {
+		return nil, err
+	}
+
+	return reqArg, nil
+}
+
+// bindReqArg bind the request into reqArg, and record the bound argument in gin context
+func bindReqArg(context *gin.Context, reqArg interface{}) error {
+	if err := bindRequest(context, reqArg); err != nil {
+		return errors.Wrap(newValidationError(reqArg, err), "bind reqArg")
 	}
 
 	b, err := json.Marshal(reqArg)
 	if err != nil {
-		return nil, errors.Wrap(err, "json marshal")
+		return errors.Wrap(err, "json marshal")
 	}
 
 	context.Set(ReqBodyLabel, string(b))
-
-	return reqArg, nil
+	return nil
 }
 
-// HandleMiddleware wrap a handler function, and return a gin-compatible processing functions
-func (h *Handler) HandleMiddleware(handleFunc interface{}) func(*gin.Context) {
+// HandleMiddleware wrap a handler function, and return a gin-compatible processing functions.
+// The route options override the configuration of the handler for this route.
+func (h *Handler) HandleMiddleware(handleFunc interface{}, opts ...RouteOption) func(*gin.Context) {
 	if err := ValidateFuncType(handleFunc); err != nil {
 		panic(err)
 	}
 
+	cfg := h.routeConfig(opts...)
+	streaming := isStreamType(reflect.TypeOf(handleFunc).Out(0))
+	if streaming {
+		cfg.timeout = 0
+	}
+
 	return func(context *gin.Context) {
-		for _, filter := range h.frontFilters {
-			if err := filter(context); err != nil {
-				h.respAdaptor.RespondErrorResp(context, err, h.handlerErrCode(err))
-				return
-			}
+		defer h.recoverPanic(context)
+		if ok := h.applyFrontFilters(context); !ok {
+			return
+		}
+		if streaming {
+			cancel := withStreamContext(context)
+			defer cancel()
+		}
+		h.handleRequest(context, handleFunc, cfg)
+	}
+}
+
+// applyFrontFilters run the front filters, respond the error and return false if any of them failed.
+// The filters are skipped if they have been applied to the request, such as by Idempotent.
+func (h *Handler) applyFrontFilters(context *gin.Context) bool {
+	if filtered, ok := context.Get(frontFilteredLabel); ok && filtered == h {
+		return true
+	}
+
+	for _, filter := range h.frontFilters {
+		if err := filter(context); err != nil {
+			h.respondError(context, err)
+			return false
+		}
+	}
+	context.Set(frontFilteredLabel, h)
+	return true
+}
+
+// applyRequestFilters run the request filters with the bound request argument
+func (h *Handler) applyRequestFilters(context *gin.Context, req interface{}) error {
+	for _, filter := range h.requestFilters {
+		if err := filter(context, req); err != nil {
+			return err
 		}
-		h.handleRequest(context, handleFunc)
 	}
+	return nil
 }
 
-func (h *Handler) handleRequest(context *gin.Context, fun handlerFun) {
-	args, err := h.buildHandleFuncArgs(fun, context)
+func (h *Handler) handleRequest(context *gin.Context, fun handlerFun, cfg *routeConfig) {
+	args, err := h.buildHandleFuncArgs(fun, context, cfg)
 	if err != nil {
-		h.respAdaptor.RespondErrorResp(context, err, h.handlerErrCode(err))
+		h.respondError(context, err)
 		return
 	}
 
-	result := callHandleFunc(fun, args...)
-	if err := result[len(result)-1]; err != nil {
-		h.respAdaptor.RespondErrorResp(context, err.(error), h.handlerErrCode(err.(error)))
+	result, err := runHandleFunc(context, cfg.timeout, func(c *gin.Context) ([]interface{}, error) {
+		callArgs := append([]interface{}{handleFuncContext(fun, c)}, args[1:]...)
+		result := callHandleFunc(fun, callArgs...)
+		if err := result[len(result)-1]; err != nil {
+			return nil, err.(error)
+		}
+		return result, nil
+	})
+	if err != nil {
+		h.respondError(context, err)
 		return
 	}
 
@@ -116,6 +227,11 @@ func (h *Handler) handleRequest(context *gin.Context, fun handlerFun) {
 		return
 	}
 
+	if len(result) == 2 && isStreamType(reflect.TypeOf(fun).Out(0)) {
+		h.stream(context, reflectSource(reflect.ValueOf(result[0])), cfg.stream)
+		return
+	}
+
 	if len(result) == 1 {
 		h.respAdaptor.RespondSuccessResp(context, struct{}{})
 		return
@@ -126,6 +242,11 @@ func (h *Handler) handleRequest(context *gin.Context, fun handlerFun) {
 
 func (h *Handler) processPaginationIfPresent(args []interface{}, result []interface{}, context *gin.Context) bool {
 	// default the last param is pagination query param
+	if cursorQuery, ok := args[len(args)-1].(*CursorQuery); ok {
+		h.respondCursorPagination(context, cursorQuery, result[0].(*CursorResult))
+		return true
+	}
+
 	query, ok := args[len(args)-1].(*PaginationQuery)
 	if !ok {
 		return false
@@ -138,7 +259,23 @@ func (h *Handler) processPaginationIfPresent(args []interface{}, result []interf
 	return true
 }
 
-func (h *Handler) buildHandleFuncArgs(fun handlerFun, context *gin.Context) ([]interface{}, error) {
+// respondCursorPagination encode the cursors of result, and respond them if the adaptor supports cursor pagination
+func (h *Handler) respondCursorPagination(context *gin.Context, query *CursorQuery, result *CursorResult) {
+	adaptor, ok := h.respAdaptor.(CursorPaginationAdaptor)
+	if !ok {
+		h.respAdaptor.RespondSuccessResp(context, result.data)
+		return
+	}
+
+	cursorProcessor, err := NewCursorProcessor(query, result, h.cursorCodec)
+	if err != nil {
+		h.respondError(context, err)
+		return
+	}
+	adaptor.RespondSuccessCursorResp(context, result.data, cursorProcessor)
+}
+
+func (h *Handler) buildHandleFuncArgs(fun handlerFun, context *gin.Context, cfg *routeConfig) ([]interface{}, error) {
 	args := []interface{}{context}
 
 	req, err := createHandleReqArg(fun, context)
@@ -146,10 +283,8 @@ func (h *Handler) buildHandleFuncArgs(fun handlerFun, context *gin.Context) ([]i
 		return nil, errors.Wrap(err, "createHandleReqArg")
 	}
 
-	for _, filter := range h.requestFilters {
-		if err := filter(context, req); err != nil {
-			return nil, err
-		}
+	if err := h.applyRequestFilters(context, req); err != nil {
+		return nil, err
 	}
 
 	if req != nil {
@@ -158,12 +293,20 @@ func (h *Handler) buildHandleFuncArgs(fun handlerFun, context *gin.Context) ([]i
 
 	ft := reflect.TypeOf(fun)
 
+	if ft.In(ft.NumIn()-1) == cursorQueryType {
+		query, err := ParseCursorWithOptions(context, h.cursorCodec, cfg.pagination)
+		if err != nil {
+			return nil, errors.Wrap(err, "ParseCursor")
+		}
+		return append(args, query), nil
+	}
+
 	// not exist pagination
 	if ft.In(ft.NumIn()-1) != paginationQueryType {
 		return args, nil
 	}
 
-	query, err := ParsePagination(context)
+	query, err := ParsePaginationWithOptions(context, cfg.pagination)
 	if err != nil {
 		return nil, errors.Wrap(err, "ParsePagination")
 	}
@@ -177,6 +320,8 @@ var (
 	contextType          = reflect.TypeOf((*gin.Context)(nil))
 	paginationQueryType  = reflect.TypeOf((*PaginationQuery)(nil))
 	paginationResultType = reflect.TypeOf((*PaginationResult)(nil))
+	cursorQueryType      = reflect.TypeOf((*CursorQuery)(nil))
+	cursorResultType     = reflect.TypeOf((*CursorResult)(nil))
 )
 
 // ValidateFuncType used to validate the handler function's argumetns and return value
@@ -190,8 +335,8 @@ func ValidateFuncType(fun handlerFun) error {
 		return errors.New("need one or two or three parameters in " + ft.String())
 	}
 
-	if ft.In(0) != contextType {
-		return errors.New("the first parameter must point of context in " + ft.String())
+	if ft.In(0) != contextType && ft.In(0) != stdContextType {
+		return errors.New("the first parameter must point of context or context.Context in " + ft.String())
 	}
 
 	if ft.NumIn() == 2 && ft.In(1).Kind() != reflect.Ptr {
@@ -208,17 +353,13 @@ func ValidateFuncType(fun handlerFun) error {
 		return errors.New("the first return value of pagination must paginationResultType in " + ft.String())
 	}
 
+	hasCursor := ft.In(ft.NumIn()-1) == cursorQueryType
+	if hasCursor && ft.Out(0) != cursorResultType {
+		return errors.New("the first return value of cursor pagination must cursorResultType in " + ft.String())
+	}
+
 	if !ft.Out(ft.NumOut() - 1).Implements(errorType) {
 		return errors.New("the last return value must error in " + ft.String())
 	}
 	return nil
 }
-
-func (h *Handler) handlerErrCode(err error) int {
-	root := errors.Root(err)
-	if errCode, ok := h.errorCodes[root]; ok {
-		return errCode
-	}
-
-	return 0
-}
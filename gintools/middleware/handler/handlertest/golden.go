@@ -0,0 +1,59 @@
+package handlertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+)
+
+// updateGolden rewrite the golden files instead of comparing with them, such as go test -update-golden
+var updateGolden = flag.Bool("update-golden", false, "update the golden files of handlertest")
+
+// GoldenDir is the directory of the golden files
+var GoldenDir = "testdata"
+
+// AssertGolden assert the response body equals the golden file testdata/<name>.golden, the json bodies are
+// compared after indenting them with the object keys sorted. Run the tests with -update-golden to rewrite the files.
+func (r *Result) AssertGolden(name string) *Result {
+	r.t.Helper()
+
+	got := r.normalizedBody()
+	path := filepath.Join(GoldenDir, name+".golden")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			r.t.Fatalf("handlertest: create golden dir: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			r.t.Fatalf("handlertest: write golden file: %v", err)
+		}
+		return r
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		r.t.Fatalf("handlertest: read golden file, run with -update-golden to create it: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		r.t.Errorf("response differs from %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+	return r
+}
+
+// normalizedBody return the indented json body with the object keys sorted, other bodies are returned as they are
+func (r *Result) normalizedBody() []byte {
+	v, err := decodeJSON(r.Body)
+	if err != nil {
+		return r.Body
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return r.Body
+	}
+	return buf.Bytes()
+}
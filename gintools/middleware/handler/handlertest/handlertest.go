@@ -0,0 +1,283 @@
+// Package handlertest provide a harness to invoke the handler functions in process, and to assert the responses
+// decoded from the response envelope of the handler
+package handlertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// Harness invoke the handler functions with the handler
+type Harness struct {
+	Handler *handler.Handler
+}
+
+// New return a harness whose handler is derived from an empty handler by the options, such as
+// handler.WithErrorCodes and handler.WithFrontFilters
+func New(opts ...handler.HandlerOption) *Harness {
+	return NewWithHandler(handler.NewHandler(nil, nil, nil).With(opts...))
+}
+
+// NewWithHandler return a harness of the handler, call gin.SetMode(gin.TestMode) in the init of the tests
+// to silence the debug logs of gin
+func NewWithHandler(h *handler.Handler) *Harness {
+	return &Harness{Handler: h}
+}
+
+// Request describe the request to invoke the handler function with
+type Request struct {
+	// Method defaults to POST if Body is set, otherwise GET
+	Method string
+	// Path is the route of the handler function such as /orders/:id, it defaults to /
+	Path string
+	// Params are the values of the path params of the route
+	Params map[string]string
+	Query  url.Values
+	Header http.Header
+	// Body is encoded as the json body, unless it is a string or []byte which is sent as it is
+	Body interface{}
+}
+
+// Do invoke the handler function wrapped by HandleMiddleware with the route options
+func (h *Harness) Do(t testing.TB, handleFunc interface{}, req Request, opts ...handler.RouteOption) *Result {
+	t.Helper()
+	return h.Serve(t, h.Handler.HandleMiddleware(handleFunc, opts...), req)
+}
+
+// Serve invoke the gin processing function, such as the ones returned by handler.JSON and handler.Paginated
+func (h *Harness) Serve(t testing.TB, handle gin.HandlerFunc, req Request) *Result {
+	t.Helper()
+
+	route := req.Path
+	if route == "" {
+		route = "/"
+	}
+	method := req.Method
+	if method == "" {
+		method = http.MethodGet
+		if req.Body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	body, err := encodeBody(req.Body)
+	if err != nil {
+		t.Fatalf("handlertest: encode request body: %v", err)
+	}
+
+	r := httptest.NewRequest(method, target(route, req.Params, req.Query), body)
+	for key, values := range req.Header {
+		r.Header[key] = values
+	}
+	if req.Body != nil && r.Header.Get("Content-Type") == "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+
+	engine := gin.New()
+	engine.Handle(method, route, handle)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	return newResult(t, w)
+}
+
+func encodeBody(body interface{}) (io.Reader, error) {
+	switch b := body.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return strings.NewReader(b), nil
+	case []byte:
+		return bytes.NewReader(b), nil
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(b), nil
+}
+
+// target return the request target of the route with the path params replaced by the values
+func target(route string, params map[string]string, query url.Values) string {
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = url.PathEscape(params[segment[1:]])
+		}
+	}
+
+	path := strings.Join(segments, "/")
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path
+}
+
+// Envelope is the decoded response envelope of the handler. The problem details of ProblemResponse are decoded
+// into it too, whose Msg is the detail, or the title if the detail is absent.
+type Envelope struct {
+	Code       int                     `json:"code"`
+	Msg        string                  `json:"msg"`
+	Data       json.RawMessage         `json:"data,omitempty"`
+	Errors     []handler.FieldError    `json:"errors,omitempty"`
+	Pagination *handler.PaginationResp `json:"pagination,omitempty"`
+}
+
+// Result is the response of the handler function, the assertions fail the test and return the result for chaining
+type Result struct {
+	Status   int
+	Header   http.Header
+	Body     []byte
+	Envelope Envelope
+
+	t testing.TB
+}
+
+func newResult(t testing.TB, w *httptest.ResponseRecorder) *Result {
+	t.Helper()
+
+	r := &Result{Status: w.Code, Header: w.Header(), Body: w.Body.Bytes(), t: t}
+	if len(r.Body) == 0 {
+		return r
+	}
+
+	contentType := strings.TrimSpace(strings.Split(w.Header().Get("Content-Type"), ";")[0])
+	if contentType != gin.MIMEJSON && !strings.HasSuffix(contentType, "+json") {
+		return r
+	}
+
+	if err := json.Unmarshal(r.Body, &r.Envelope); err != nil {
+		t.Fatalf("handlertest: decode response envelope %s: %v", r.Body, err)
+	}
+	if contentType == handler.MIMEProblemJSON {
+		var problem struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}
+		if err := json.Unmarshal(r.Body, &problem); err != nil {
+			t.Fatalf("handlertest: decode problem details %s: %v", r.Body, err)
+		}
+		r.Envelope.Msg = problem.Detail
+		if r.Envelope.Msg == "" {
+			r.Envelope.Msg = problem.Title
+		}
+	}
+	return r
+}
+
+// DecodeData decode the data of the envelope into v
+func (r *Result) DecodeData(v interface{}) *Result {
+	r.t.Helper()
+	if err := json.Unmarshal(r.Envelope.Data, v); err != nil {
+		r.t.Fatalf("handlertest: decode data %s: %v", r.Envelope.Data, err)
+	}
+	return r
+}
+
+// AssertStatus assert the HTTP status of the response
+func (r *Result) AssertStatus(status int) *Result {
+	r.t.Helper()
+	if r.Status != status {
+		r.t.Errorf("status = %d, want %d, body = %s", r.Status, status, r.Body)
+	}
+	return r
+}
+
+// AssertSuccess assert the response is a success envelope
+func (r *Result) AssertSuccess() *Result {
+	r.t.Helper()
+	if r.Envelope.Code != http.StatusOK {
+		r.t.Errorf("code = %d, msg = %q, want success", r.Envelope.Code, r.Envelope.Msg)
+	}
+	return r
+}
+
+// AssertCode assert the code of the envelope
+func (r *Result) AssertCode(code int) *Result {
+	r.t.Helper()
+	if r.Envelope.Code != code {
+		r.t.Errorf("code = %d, msg = %q, want code %d", r.Envelope.Code, r.Envelope.Msg, code)
+	}
+	return r
+}
+
+// AssertMsg assert the msg of the envelope
+func (r *Result) AssertMsg(msg string) *Result {
+	r.t.Helper()
+	if r.Envelope.Msg != msg {
+		r.t.Errorf("msg = %q, want %q", r.Envelope.Msg, msg)
+	}
+	return r
+}
+
+// AssertData assert the data of the envelope equals the json encoding of want
+func (r *Result) AssertData(want interface{}) *Result {
+	r.t.Helper()
+	b, err := json.Marshal(want)
+	if err != nil {
+		r.t.Fatalf("handlertest: encode want: %v", err)
+	}
+
+	var got interface{}
+	if len(r.Envelope.Data) > 0 {
+		if got, err = decodeJSON(r.Envelope.Data); err != nil {
+			r.t.Fatalf("handlertest: decode data: %v", err)
+		}
+	}
+	expected, err := decodeJSON(b)
+	if err != nil {
+		r.t.Fatalf("handlertest: decode want: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, expected) {
+		r.t.Errorf("data = %s, want %s", r.Envelope.Data, b)
+	}
+	return r
+}
+
+// AssertFieldError assert the validation errors of the envelope contain the field path, with the rule if it is not empty
+func (r *Result) AssertFieldError(path, rule string) *Result {
+	r.t.Helper()
+	for _, field := range r.Envelope.Errors {
+		if field.Path == path && (rule == "" || field.Rule == rule) {
+			return r
+		}
+	}
+	r.t.Errorf("errors = %+v, want the error of path %q rule %q", r.Envelope.Errors, path, rule)
+	return r
+}
+
+// AssertPagination assert the start, limit and total of the offset pagination
+func (r *Result) AssertPagination(start, limit, total uint64) *Result {
+	r.t.Helper()
+	pagination := r.Envelope.Pagination
+	if pagination == nil || pagination.Pagination == nil {
+		r.t.Errorf("pagination is absent, want start %d limit %d total %d", start, limit, total)
+		return r
+	}
+
+	if got := pagination.Pagination; got.Start != start || got.Limit != limit || got.Total != total {
+		r.t.Errorf("pagination = start %d limit %d total %d, want start %d limit %d total %d", got.Start, got.Limit, got.Total, start, limit, total)
+	}
+	return r
+}
+
+// decodeJSON decode the json value with the numbers kept as json.Number, so that the big integers are compared exactly
+func decodeJSON(b []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var v interface{}
+	err := decoder.Decode(&v)
+	return v, err
+}
@@ -0,0 +1,110 @@
+package handlertest
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+	"github.com/gin-gonic/gin"
+)
+
+var errNoAccess = errors.New("no access")
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type order struct {
+	ID     uint64 `json:"id"`
+	Amount uint64 `json:"amount"`
+	Memo   string `json:"memo,omitempty"`
+}
+
+type getOrderReq struct {
+	ID    uint64 `json:"-" uri:"id"`
+	Token string `json:"-" header:"X-Token"`
+}
+
+type createOrderReq struct {
+	Amount uint64 `json:"amount" binding:"required"`
+	Memo   string `json:"memo" query:"memo"`
+}
+
+func newHarness() *Harness {
+	return New(
+		handler.WithErrorCodes(map[error]int{errNoAccess: 40300}),
+		handler.WithFrontFilters(func(c *gin.Context) error {
+			if c.GetHeader("X-Token") == "" {
+				return errNoAccess
+			}
+			return nil
+		}),
+	)
+}
+
+func getOrder(c *gin.Context, req *getOrderReq) (*order, error) {
+	return &order{ID: req.ID, Amount: 100}, nil
+}
+
+func TestHarnessDo(t *testing.T) {
+	h := newHarness()
+
+	h.Do(t, getOrder, Request{
+		Path:   "/orders/:id",
+		Params: map[string]string{"id": "7"},
+		Header: http.Header{"X-Token": {"secret"}},
+	}).AssertStatus(http.StatusOK).AssertSuccess().AssertData(order{ID: 7, Amount: 100}).AssertGolden("get_order")
+
+	var created order
+	h.Do(t, func(c *gin.Context, req *createOrderReq) (*order, error) {
+		return &order{ID: 1, Amount: req.Amount, Memo: req.Memo}, nil
+	}, Request{
+		Body:   createOrderReq{Amount: 18446744073709551615},
+		Query:  url.Values{"memo": {"from query"}},
+		Header: http.Header{"X-Token": {"secret"}},
+	}).AssertSuccess().DecodeData(&created)
+	if created.Amount != 18446744073709551615 || created.Memo != "from query" {
+		t.Errorf("created = %+v, want the amount of the body and the memo of the query", created)
+	}
+}
+
+func TestHarnessErrors(t *testing.T) {
+	h := newHarness()
+	h.Handler.SetValidationErrCode(40000)
+
+	h.Do(t, getOrder, Request{Path: "/orders/:id", Params: map[string]string{"id": "7"}}).
+		AssertCode(40300).AssertMsg("no access").AssertGolden("no_access")
+
+	h.Do(t, func(c *gin.Context, req *createOrderReq) (*order, error) {
+		return nil, nil
+	}, Request{Body: `{}`, Header: http.Header{"X-Token": {"secret"}}}).
+		AssertCode(40000).AssertFieldError("amount", "required")
+}
+
+func TestHarnessProblem(t *testing.T) {
+	h := newHarness()
+	h.Handler.SetValidationErrCode(40000).SetResponseAdaptor(&handler.ProblemResponse{})
+
+	h.Do(t, getOrder, Request{Path: "/orders/:id", Params: map[string]string{"id": "7"}}).
+		AssertStatus(http.StatusBadRequest).AssertCode(40300).AssertMsg("no access")
+
+	h.Do(t, func(c *gin.Context, req *createOrderReq) (*order, error) {
+		return nil, nil
+	}, Request{Body: `{}`, Header: http.Header{"X-Token": {"secret"}}}).
+		AssertCode(40000).AssertFieldError("amount", "required")
+}
+
+func TestHarnessServe(t *testing.T) {
+	h := newHarness()
+	list := handler.Paginated(h.Handler, func(c *gin.Context, req *handler.Empty, query *handler.PaginationQuery) ([]order, uint64, error) {
+		return []order{{ID: query.Start + 1}}, 3, nil
+	})
+
+	h.Serve(t, list, Request{
+		Path:   "/orders",
+		Query:  url.Values{"start": {"1"}, "limit": {"1"}},
+		Header: http.Header{"X-Token": {"secret"}},
+	}).AssertSuccess().AssertPagination(1, 1, 3).AssertData([]order{{ID: 2}}).AssertGolden("list_orders")
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// the headers of the idempotent request
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+const (
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyLockTTL     = time.Minute
+	defaultIdempotencyMaxBodySize = 1 << 20
+)
+
+var (
+	// ErrIdempotencyInFlight is returned when the request of the same idempotency key is still being processed
+	ErrIdempotencyInFlight = errors.New("request with the same idempotency key is in flight")
+	// ErrBodyTooLarge is responded with HTTP 413 when the request body exceeds the max body size
+	ErrBodyTooLarge = errors.New("request body too large")
+)
+
+// IdempotentResponse is the recorded response of an idempotent request
+type IdempotentResponse struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// IdempotencyStore store the responses of the idempotent requests
+type IdempotencyStore interface {
+	// Begin return the stored response of the key, or reserve the key for the request which expires after lockTTL
+	// and return nil. It returns ErrIdempotencyInFlight if the key is reserved by another request.
+	Begin(key string, lockTTL time.Duration) (*IdempotentResponse, error)
+	// Complete store the response of the reserved key, which expires after ttl
+	Complete(key string, resp *IdempotentResponse, ttl time.Duration) error
+	// Release release the reserved key without response, so the request can be retried
+	Release(key string) error
+}
+
+type idempotencyEntry struct {
+	resp   *IdempotentResponse
+	expiry time.Time
+}
+
+// MemoryIdempotencyStore is an in-memory IdempotencyStore for single instance services
+type MemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	entries map[string]idempotencyEntry
+	now     func() time.Time
+	// sweepAt is the time to remove the expired entries next
+	sweepAt time.Time
+}
+
+// NewMemoryIdempotencyStore return an empty in-memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]idempotencyEntry), now: time.Now}
+}
+
+// Begin return the stored response or reserve the key, the expired entries are removed at most once per lockTTL
+func (s *MemoryIdempotencyStore) Begin(key string, lockTTL time.Duration) (*IdempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if !now.Before(s.sweepAt) {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiry) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweepAt = now.Add(lockTTL)
+	}
+
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiry) {
+		if entry.resp == nil {
+			return nil, ErrIdempotencyInFlight
+		}
+		return entry.resp, nil
+	}
+
+	s.entries[key] = idempotencyEntry{expiry: now.Add(lockTTL)}
+	return nil, nil
+}
+
+// Complete store the response of the key
+func (s *MemoryIdempotencyStore) Complete(key string, resp *IdempotentResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = idempotencyEntry{resp: resp, expiry: s.now().Add(ttl)}
+	return nil
+}
+
+// Release remove the reserved key
+func (s *MemoryIdempotencyStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// IdempotencyOptions is the options of the idempotent routes
+type IdempotencyOptions struct {
+	// Store store the responses, a MemoryIdempotencyStore is used if it is nil
+	Store IdempotencyStore
+	// TTL is how long the response is replayed, default is 24 hours
+	TTL time.Duration
+	// LockTTL is how long the key is reserved if the request never completes, default is 1 minute
+	LockTTL time.Duration
+	// Required reject the requests without the Idempotency-Key header as validation errors
+	Required bool
+	// InFlightCode is the error code responded with HTTP 409 when the same request is in flight
+	InFlightCode int
+	// MaxBodySize is the max bytes of the request body to be hashed, default is 1MB
+	MaxBodySize int64
+	// TooLargeCode is the error code responded with HTTP 413 when the request body exceeds MaxBodySize
+	TooLargeCode int
+}
+
+// Idempotent wrap the handler function returned by HandleMiddleware, such as
+// h.Idempotent(h.HandleMiddleware(submitTx), opts). The front filters of the handler are applied before the
+// stored response is looked up, and they are not applied again by the wrapped function. The first response of
+// the Idempotency-Key is stored by the authenticated client, the key, method, path and body hash, and it is
+// replayed with the Idempotent-Replayed header for the duplicates. The responses of HTTP 5xx are not stored,
+// so the request can be retried.
+func (h *Handler) Idempotent(next gin.HandlerFunc, opts IdempotencyOptions) gin.HandlerFunc {
+	if opts.Store == nil {
+		opts.Store = NewMemoryIdempotencyStore()
+	}
+	if opts.TTL == 0 {
+		opts.TTL = defaultIdempotencyTTL
+	}
+	if opts.LockTTL == 0 {
+		opts.LockTTL = defaultIdempotencyLockTTL
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaultIdempotencyMaxBodySize
+	}
+
+	return func(c *gin.Context) {
+		defer h.recoverPanic(c)
+		if ok := h.applyFrontFilters(c); !ok {
+			return
+		}
+
+		idempotencyKey := c.GetHeader(HeaderIdempotencyKey)
+		if idempotencyKey == "" {
+			if opts.Required {
+				h.respondError(c, &ValidationError{Fields: []FieldError{{
+					Field:   HeaderIdempotencyKey,
+					Path:    HeaderIdempotencyKey,
+					Source:  SourceHeader,
+					Rule:    "required",
+					Message: validationMessage(HeaderIdempotencyKey, "required", ""),
+				}}})
+				return
+			}
+			next(c)
+			return
+		}
+
+		key, err := idempotencyStoreKey(c, idempotencyKey, opts.MaxBodySize)
+		if err == ErrBodyTooLarge {
+			h.respondErrorSpec(c, err, ErrorSpec{HTTPStatus: http.StatusRequestEntityTooLarge, Code: opts.TooLargeCode})
+			return
+		}
+		if err != nil {
+			h.respondError(c, err)
+			return
+		}
+
+		stored, err := opts.Store.Begin(key, opts.LockTTL)
+		if err == ErrIdempotencyInFlight {
+			h.respondErrorSpec(c, err, ErrorSpec{HTTPStatus: http.StatusConflict, Code: opts.InFlightCode})
+			return
+		}
+		if err != nil {
+			h.respondError(c, errors.Wrap(err, "begin idempotent request"))
+			return
+		}
+
+		if stored != nil {
+			replayResponse(c, stored)
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				opts.Store.Release(key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		next(c)
+		c.Writer = recorder.ResponseWriter
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		resp := &IdempotentResponse{Status: recorder.Status(), Header: recorder.Header().Clone(), Body: recorder.body.Bytes()}
+		if err := opts.Store.Complete(key, resp, opts.TTL); err == nil {
+			completed = true
+		}
+	}
+}
+
+// idempotencyStoreKey return the store key of the request, which is scoped to the authenticated client.
+// The body of at most maxBodySize bytes is restored for the binding afterwards.
+func idempotencyStoreKey(c *gin.Context, idempotencyKey string, maxBodySize int64) (string, error) {
+	var body []byte
+	if c.Request.Body != nil {
+		var err error
+		if body, err = io.ReadAll(io.LimitReader(c.Request.Body, maxBodySize+1)); err != nil {
+			return "", errors.Wrap(err, "read body")
+		}
+		if int64(len(body)) > maxBodySize {
+			return "", ErrBodyTooLarge
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	bodyHash := sha256.Sum256(body)
+	return fmt.Sprintf("%q %q %s %s %s", c.GetString(ContextKeyClient), idempotencyKey, c.Request.Method, c.Request.URL.Path, hex.EncodeToString(bodyHash[:])), nil
+}
+
+func replayResponse(c *gin.Context, resp *IdempotentResponse) {
+	for key, values := range resp.Header {
+		c.Writer.Header()[key] = values
+	}
+	c.Header(HeaderIdempotentReplayed, "true")
+	c.Status(resp.Status)
+	c.Writer.Write(resp.Body)
+	c.Abort()
+}
+
+// responseRecorder record the body written to the gin response writer
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
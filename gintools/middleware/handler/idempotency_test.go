@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type submitReq struct {
+	Tx string `json:"tx"`
+}
+
+func TestIdempotent(t *testing.T) {
+	errBroken := errors.New("node broken")
+	h := NewHandlerWithErrorSpecs(map[error]ErrorSpec{errBroken: {HTTPStatus: http.StatusBadGateway, Code: 502}}, nil, nil).
+		SetResponseAdaptor(&StatusResponse{})
+	store := NewMemoryIdempotencyStore()
+
+	submitted := 0
+	engine := gin.New()
+	engine.POST("/tx", h.Idempotent(h.HandleMiddleware(func(c *gin.Context, req *submitReq) (string, error) {
+		if req.Tx == "broken" {
+			return "", errBroken
+		}
+		submitted++
+		return req.Tx + "-hash", nil
+	}), IdempotencyOptions{Store: store, InFlightCode: 40900}))
+
+	submit := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/tx", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			r.Header.Set(HeaderIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		return w
+	}
+
+	first := submit("k1", `{"tx":"a"}`)
+	replayed := submit("k1", `{"tx":"a"}`)
+	if submitted != 1 || replayed.Body.String() != first.Body.String() || replayed.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Errorf("submitted %d times, replayed %q %v, want the first response replayed", submitted, replayed.Body.String(), replayed.Header())
+	}
+	if replayed.Header().Get("Content-Type") != first.Header().Get("Content-Type") {
+		t.Errorf("Content-Type = %q, want the recorded header", replayed.Header().Get("Content-Type"))
+	}
+
+	submit("k1", `{"tx":"b"}`)
+	submit("", `{"tx":"a"}`)
+	if submitted != 3 {
+		t.Errorf("submitted %d times, want the different body and the request without key processed", submitted)
+	}
+
+	if w := submit("k2", `{"tx":"broken"}`); w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", w.Code)
+	}
+	if _, err := store.Begin(storeKey("", "k2", `{"tx":"broken"}`), defaultIdempotencyLockTTL); err != nil {
+		t.Errorf("Begin() error = %v, want the 5xx response released", err)
+	}
+
+	store.Begin(storeKey("", "k3", `{"tx":"c"}`), defaultIdempotencyLockTTL)
+	if w := submit("k3", `{"tx":"c"}`); w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), "40900") {
+		t.Errorf("in flight = %d %s, want 409 with the in-flight code", w.Code, w.Body.String())
+	}
+}
+
+func TestIdempotentRequired(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetValidationErrCode(40000)
+	fun := h.Idempotent(h.HandleMiddleware(func(c *gin.Context) (string, error) {
+		return "ok", nil
+	}), IdempotencyOptions{Required: true})
+
+	resp := serve(http.MethodPost, "/tx", "", fun)
+	if resp.Code != 40000 || len(resp.Errors) != 1 || resp.Errors[0].Source != SourceHeader {
+		t.Errorf("response = %+v, want the missing header validation error", resp)
+	}
+}
+
+func storeKey(client, idempotencyKey, body string) string {
+	c := &gin.Context{Request: httptest.NewRequest(http.MethodPost, "/tx", strings.NewReader(body))}
+	if client != "" {
+		c.Set(ContextKeyClient, client)
+	}
+	key, _ := idempotencyStoreKey(c, idempotencyKey, defaultIdempotencyMaxBodySize)
+	return key
+}
+
+func TestIdempotentFilters(t *testing.T) {
+	errUnauthorized := errors.New("unauthorized")
+	filtered := 0
+	auth := func(c *gin.Context) error {
+		filtered++
+		client := c.GetHeader("X-Client")
+		if client == "" {
+			return errUnauthorized
+		}
+		c.Set(ContextKeyClient, client)
+		return nil
+	}
+	h := NewHandler(map[error]int{errUnauthorized: 40100}, []FrontFilter{auth}, nil).SetResponseAdaptor(&StatusResponse{})
+
+	engine := gin.New()
+	engine.POST("/tx", h.Idempotent(h.HandleMiddleware(func(c *gin.Context, req *submitReq) (string, error) {
+		return c.GetString(ContextKeyClient) + ":" + req.Tx, nil
+	}), IdempotencyOptions{MaxBodySize: 16, TooLargeCode: 41300}))
+
+	submit := func(client, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/tx", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set(HeaderIdempotencyKey, "k")
+		if client != "" {
+			r.Header.Set("X-Client", client)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := submit("alice", `{"tx":"a"}`); !strings.Contains(w.Body.String(), "alice:a") || filtered != 1 {
+		t.Fatalf("first = %s, filtered %d times, want the response of alice filtered once", w.Body.String(), filtered)
+	}
+	if w := submit("", `{"tx":"a"}`); w.Code != http.StatusBadRequest || w.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("unauthorized = %d %v, want rejected by the front filter instead of replayed", w.Code, w.Header())
+	}
+	if w := submit("bob", `{"tx":"a"}`); !strings.Contains(w.Body.String(), "bob:a") || w.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Errorf("other client = %s, want the key scoped to the client", w.Body.String())
+	}
+	if w := submit("alice", `{"tx":"toolarge"}`); w.Code != http.StatusRequestEntityTooLarge || !strings.Contains(w.Body.String(), "41300") {
+		t.Errorf("large body = %d %s, want 413 with the too large code", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the relations of the pagination links, in the order they are written to the Link header
+var linkRelations = []string{"self", "first", "prev", "next", "last"}
+
+type links struct {
+	Self  string `json:"self,omitempty" xml:"self,omitempty"`
+	First string `json:"first,omitempty" xml:"first,omitempty"`
+	Prev  string `json:"prev,omitempty" xml:"prev,omitempty"`
+	Next  string `json:"next,omitempty" xml:"next,omitempty"`
+	Last  string `json:"last,omitempty" xml:"last,omitempty"`
+}
+
+func (l links) byRelation(rel string) string {
+	switch rel {
+	case "self":
+		return l.Self
+	case "first":
+		return l.First
+	case "prev":
+		return l.Prev
+	case "next":
+		return l.Next
+	default:
+		return l.Last
+	}
+}
+
+// header format the links as the value of the RFC 8288 Link header
+func (l links) header() string {
+	var values []string
+	for _, rel := range linkRelations {
+		if link := l.byRelation(rel); link != "" {
+			values = append(values, "<"+link+`>; rel="`+rel+`"`)
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// linkFunc build a link of the current request, whose pagination params are replaced by params
+type linkFunc func(params map[string]string) string
+
+// LinkBuilder build the pagination links from the request URL, the query params except the pagination ones are kept
+type LinkBuilder struct {
+	// Absolute make the links absolute URLs, the scheme and host are taken from the request
+	Absolute bool
+
+	// TrustedProxies are the proxies whose X-Forwarded-Proto and X-Forwarded-Host headers are trusted
+	TrustedProxies []*net.IPNet
+}
+
+// ParseTrustedProxies parse the CIDRs or IPs of the trusted proxies
+func ParseTrustedProxies(proxies ...string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// linker return the linkFunc of the request, the paginationParams are removed from the query of all links
+func (b *LinkBuilder) linker(c *gin.Context, paginationParams ...string) linkFunc {
+	base := &url.URL{Path: c.Request.URL.Path}
+	if b != nil && b.Absolute {
+		base.Scheme, base.Host = b.origin(c)
+	}
+
+	query := c.Request.URL.Query()
+	for _, param := range paginationParams {
+		query.Del(param)
+	}
+
+	return func(params map[string]string) string {
+		values := url.Values{}
+		for key, vals := range query {
+			values[key] = vals
+		}
+		for key, val := range params {
+			values.Set(key, val)
+		}
+
+		link := *base
+		link.RawQuery = values.Encode()
+		return link.String()
+	}
+}
+
+// origin return the scheme and host of the request, the forwarded ones are used if the peer is a trusted proxy
+func (b *LinkBuilder) origin(c *gin.Context) (string, string) {
+	scheme, host := "http", c.Request.Host
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+
+	if !IsTrustedProxy(c.Request.RemoteAddr, b.TrustedProxies) {
+		return scheme, host
+	}
+
+	if proto := firstHeaderValue(c.GetHeader("X-Forwarded-Proto")); proto != "" {
+		scheme = proto
+	}
+	if forwardedHost := firstHeaderValue(c.GetHeader("X-Forwarded-Host")); forwardedHost != "" {
+		host = forwardedHost
+	}
+	return scheme, host
+}
+
+// IsTrustedProxy report whether the address, an IP with or without the port, is one of the trusted proxies
+func IsTrustedProxy(addr string, proxies []*net.IPNet) bool {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(addr))
+	if err != nil {
+		host = strings.TrimSpace(addr)
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+
+	for _, proxy := range proxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstHeaderValue(value string) string {
+	return strings.TrimSpace(strings.Split(value, ",")[0])
+}
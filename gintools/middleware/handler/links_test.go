@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestPaginationLinks(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		builder    *LinkBuilder
+		remoteAddr string
+		wantLinks  links
+		wantHeader string
+	}{
+		{
+			name:       "relative",
+			remoteAddr: "10.0.0.1:80",
+			wantLinks: links{
+				Self:  "/txs?asset=btm&limit=10&start=10",
+				First: "/txs?asset=btm&limit=10&start=0",
+				Prev:  "/txs?asset=btm&limit=10&start=0",
+				Next:  "/txs?asset=btm&limit=10&start=20",
+				Last:  "/txs?asset=btm&limit=10&start=40",
+			},
+			wantHeader: `</txs?asset=btm&limit=10&start=10>; rel="self", </txs?asset=btm&limit=10&start=0>; rel="first", ` +
+				`</txs?asset=btm&limit=10&start=0>; rel="prev", </txs?asset=btm&limit=10&start=20>; rel="next", ` +
+				`</txs?asset=btm&limit=10&start=40>; rel="last"`,
+		},
+		{
+			name:       "trusted proxy",
+			builder:    &LinkBuilder{Absolute: true, TrustedProxies: proxies},
+			remoteAddr: "10.0.0.1:80",
+			wantLinks: links{
+				Self:  "https://api.bytom.io/txs?asset=btm&limit=10&start=10",
+				First: "https://api.bytom.io/txs?asset=btm&limit=10&start=0",
+				Prev:  "https://api.bytom.io/txs?asset=btm&limit=10&start=0",
+				Next:  "https://api.bytom.io/txs?asset=btm&limit=10&start=20",
+				Last:  "https://api.bytom.io/txs?asset=btm&limit=10&start=40",
+			},
+		},
+		{
+			name:       "untrusted proxy",
+			builder:    &LinkBuilder{Absolute: true, TrustedProxies: proxies},
+			remoteAddr: "192.168.0.1:80",
+			wantLinks: links{
+				Self:  "http://internal/txs?asset=btm&limit=10&start=10",
+				First: "http://internal/txs?asset=btm&limit=10&start=0",
+				Prev:  "http://internal/txs?asset=btm&limit=10&start=0",
+				Next:  "http://internal/txs?asset=btm&limit=10&start=20",
+				Last:  "http://internal/txs?asset=btm&limit=10&start=40",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "http://internal/txs?asset=btm&start=10&limit=10", nil)
+			c.Request.RemoteAddr = tt.remoteAddr
+			c.Request.Header.Set("X-Forwarded-Proto", "https")
+			c.Request.Header.Set("X-Forwarded-Host", "api.bytom.io, internal")
+
+			adaptor := &StandardResponse{Links: tt.builder}
+			processor := NewPaginationProcessor(&PaginationQuery{Start: 10, Limit: 10}, 45)
+			resp := adaptor.formatPaginationResp(c, nil, processor)
+			if resp.Pagination.Links != tt.wantLinks {
+				t.Errorf("links = %+v, want %+v", resp.Pagination.Links, tt.wantLinks)
+			}
+			if tt.wantHeader != "" && w.Header().Get("Link") != tt.wantHeader {
+				t.Errorf("Link header = %s, want %s", w.Header().Get("Link"), tt.wantHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,350 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+	"github.com/ugorji/go/codec"
+)
+
+// the headers which carry the envelope when the protobuf body is the data only
+const (
+	HeaderResponseCode = "X-Response-Code"
+	HeaderResponseMsg  = "X-Response-Msg"
+)
+
+var errNotProtoMessage = errors.New("response data is not a protobuf message")
+
+// Encoder render the response envelope in a specified format
+type Encoder interface {
+	ContentType() string
+	Encode(c *gin.Context, w io.Writer, resp *Response) error
+}
+
+// JSONEncoder render the response as JSON
+type JSONEncoder struct{}
+
+// ContentType return the content type of JSON
+func (JSONEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+// Encode write the response as JSON
+func (JSONEncoder) Encode(c *gin.Context, w io.Writer, resp *Response) error {
+	return json.NewEncoder(w).Encode(resp)
+}
+
+// XMLEncoder render the response as XML, whose root element is <response>
+type XMLEncoder struct{}
+
+// ContentType return the content type of XML
+func (XMLEncoder) ContentType() string {
+	return "application/xml; charset=utf-8"
+}
+
+// Encode write the response as XML
+func (XMLEncoder) Encode(c *gin.Context, w io.Writer, resp *Response) error {
+	return xml.NewEncoder(w).EncodeElement(resp, xml.StartElement{Name: xml.Name{Local: "response"}})
+}
+
+// MsgPackEncoder render the response as MessagePack, the field names follow the json tags
+type MsgPackEncoder struct{}
+
+// ContentType return the content type of MessagePack
+func (MsgPackEncoder) ContentType() string {
+	return "application/msgpack"
+}
+
+// Encode write the response as MessagePack
+func (MsgPackEncoder) Encode(c *gin.Context, w io.Writer, resp *Response) error {
+	return codec.NewEncoder(w, new(codec.MsgpackHandle)).Encode(resp)
+}
+
+// ProtobufEncoder render the response as protobuf. The Envelope converts the response to the service's envelope
+// message; if it is nil, the data must be a protobuf message, and the code and msg are carried in the headers.
+type ProtobufEncoder struct {
+	Envelope func(resp *Response) (proto.Message, error)
+}
+
+// ContentType return the content type of protobuf
+func (e *ProtobufEncoder) ContentType() string {
+	return binding.MIMEPROTOBUF
+}
+
+// Encode write the response as protobuf, the headers are set only if the response is encoded
+func (e *ProtobufEncoder) Encode(c *gin.Context, w io.Writer, resp *Response) error {
+	msg, err := e.message(resp)
+	if err != nil {
+		return err
+	}
+
+	var b []byte
+	if msg != nil {
+		if b, err = proto.Marshal(msg); err != nil {
+			return err
+		}
+	}
+
+	if e.Envelope == nil {
+		c.Header(HeaderResponseCode, strconv.Itoa(resp.Code))
+		c.Header(HeaderResponseMsg, resp.Msg)
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// message return the protobuf message of the response, it is nil if the response has no data and no Envelope
+func (e *ProtobufEncoder) message(resp *Response) (proto.Message, error) {
+	if e.Envelope != nil {
+		return e.Envelope(resp)
+	}
+	if resp.Data == nil {
+		return nil, nil
+	}
+
+	msg, ok := resp.Data.(proto.Message)
+	if !ok {
+		return nil, errNotProtoMessage
+	}
+	return msg, nil
+}
+
+// EncoderRegistry hold the encoders by media type, the first registered one is used when the Accept header is empty
+type EncoderRegistry struct {
+	mediaTypes []string
+	encoders   map[string]Encoder
+}
+
+// NewEncoderRegistry return an empty encoder registry
+func NewEncoderRegistry() *EncoderRegistry {
+	return &EncoderRegistry{encoders: make(map[string]Encoder)}
+}
+
+// DefaultEncoderRegistry return the registry of JSON, XML, MessagePack and protobuf encoders, the protobuf
+// encoder responds the data only, which must be a protobuf message
+func DefaultEncoderRegistry() *EncoderRegistry {
+	return NewEncoderRegistry().
+		Register(binding.MIMEJSON, JSONEncoder{}).
+		Register(binding.MIMEXML, XMLEncoder{}).
+		Register(binding.MIMEXML2, XMLEncoder{}).
+		Register(binding.MIMEMSGPACK2, MsgPackEncoder{}).
+		Register(binding.MIMEMSGPACK, MsgPackEncoder{}).
+		Register(binding.MIMEPROTOBUF, &ProtobufEncoder{})
+}
+
+// Register add an encoder of the media type, it replaces the encoder registered before with the same type
+func (r *EncoderRegistry) Register(mediaType string, encoder Encoder) *EncoderRegistry {
+	mediaType = strings.ToLower(mediaType)
+	if _, ok := r.encoders[mediaType]; !ok {
+		r.mediaTypes = append(r.mediaTypes, mediaType)
+	}
+	r.encoders[mediaType] = encoder
+	return r
+}
+
+// MediaTypes return the registered media types in order
+func (r *EncoderRegistry) MediaTypes() []string {
+	return append([]string(nil), r.mediaTypes...)
+}
+
+// Negotiate select the encoder by the Accept header, and return false if no encoder is acceptable
+func (r *EncoderRegistry) Negotiate(accept string) (Encoder, bool) {
+	encoders := r.acceptable(accept)
+	if len(encoders) == 0 {
+		return nil, false
+	}
+	return encoders[0], true
+}
+
+// acceptable return the encoders acceptable by the Accept header in the order of preference. The quality of a
+// media type is the one of the most specific media range matching it, so a range of q=0 excludes the types it
+// names, and the ties are broken by the specificity and then the order of the ranges. Only the first registered
+// encoder is acceptable if the Accept header is empty.
+func (r *EncoderRegistry) acceptable(accept string) []Encoder {
+	if len(r.mediaTypes) == 0 {
+		return nil
+	}
+
+	if strings.TrimSpace(accept) == "" {
+		return []Encoder{r.encoders[r.mediaTypes[0]]}
+	}
+
+	type candidate struct {
+		encoder Encoder
+		ranged  mediaRange
+		index   int
+	}
+
+	ranges := parseAccept(accept)
+	var candidates []candidate
+	for _, mediaType := range r.mediaTypes {
+		index := matchRange(ranges, mediaType)
+		if index < 0 || ranges[index].quality <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{encoder: r.encoders[mediaType], ranged: ranges[index], index: index})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].ranged.quality != candidates[j].ranged.quality {
+			return candidates[i].ranged.quality > candidates[j].ranged.quality
+		}
+		if candidates[i].ranged.specificity() != candidates[j].ranged.specificity() {
+			return candidates[i].ranged.specificity() > candidates[j].ranged.specificity()
+		}
+		return candidates[i].index < candidates[j].index
+	})
+
+	var encoders []Encoder
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if !seen[c.encoder.ContentType()] {
+			seen[c.encoder.ContentType()] = true
+			encoders = append(encoders, c.encoder)
+		}
+	}
+	return encoders
+}
+
+// matchRange return the index of the most specific media range matching the media type, the first one wins
+// the tie, and -1 if no range matches
+func matchRange(ranges []mediaRange, mediaType string) int {
+	best := -1
+	for i, ranged := range ranges {
+		if ranged.matches(mediaType) && (best < 0 || ranged.specificity() > ranges[best].specificity()) {
+			best = i
+		}
+	}
+	return best
+}
+
+// mediaRange is a media range of the Accept header with its quality
+type mediaRange struct {
+	typ     string
+	subtype string
+	quality float64
+}
+
+func (m mediaRange) matches(mediaType string) bool {
+	parts := strings.SplitN(mediaType, "/", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	return (m.typ == "*" || m.typ == parts[0]) && (m.subtype == "*" || m.subtype == parts[1])
+}
+
+func (m mediaRange) specificity() int {
+	switch {
+	case m.typ == "*":
+		return 0
+	case m.subtype == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// parseAccept parse the media ranges of the Accept header in order, including the ones of q=0
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		typ := strings.SplitN(strings.ToLower(strings.TrimSpace(params[0])), "/", 2)
+		if len(typ) != 2 {
+			continue
+		}
+
+		m := mediaRange{typ: typ[0], subtype: typ[1], quality: 1}
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) == 2 && strings.ToLower(kv[0]) == "q" {
+				if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+					m.quality = q
+				}
+			}
+		}
+		ranges = append(ranges, m)
+	}
+	return ranges
+}
+
+// NegotiatedResponse render the standard response envelope in the format negotiated by the Accept header,
+// it responds 406 if no acceptable format is able to encode the response, and the errors are responded with the HTTP status of ErrorSpec
+type NegotiatedResponse struct {
+	StatusResponse
+
+	// Encoders is the available formats, DefaultEncoderRegistry is used if it is nil
+	Encoders *EncoderRegistry
+}
+
+// RespondErrorResp return error response with the default HTTP status
+func (h *NegotiatedResponse) RespondErrorResp(c *gin.Context, err error, errCode int) {
+	h.RespondErrorSpec(c, err, ErrorSpec{HTTPStatus: defaultErrorStatus(errCode), Code: errCode})
+}
+
+// RespondErrorSpec return error response with the HTTP status and message of spec
+func (h *NegotiatedResponse) RespondErrorSpec(c *gin.Context, err error, spec ErrorSpec) {
+	log.WithFields(log.Fields{
+		"url":     c.Request.URL,
+		"request": c.Value(ReqBodyLabel),
+		"err":     err,
+	}).Error("respond error")
+	response := h.formatErrResp(err, spec.Code)
+	if spec.Message != "" {
+		response.Msg = spec.Message
+	}
+	h.render(c, spec.HTTPStatus, &response)
+}
+
+// RespondSuccessResp return success response
+func (h *NegotiatedResponse) RespondSuccessResp(c *gin.Context, data interface{}) {
+	h.render(c, http.StatusOK, &Response{Code: 200, Data: data})
+}
+
+// RespondSuccessPaginationResp return success response context of the pagination request
+func (h *NegotiatedResponse) RespondSuccessPaginationResp(c *gin.Context, data interface{}, paginationProcessor *PaginationProcessor) {
+	response := h.formatPaginationResp(c, data, paginationProcessor)
+	h.render(c, http.StatusOK, &response)
+}
+
+// RespondSuccessCursorResp return success response context of the cursor pagination request
+func (h *NegotiatedResponse) RespondSuccessCursorResp(c *gin.Context, data interface{}, cursorProcessor *CursorProcessor) {
+	response := h.formatCursorResp(c, data, cursorProcessor)
+	h.render(c, http.StatusOK, &response)
+}
+
+func (h *NegotiatedResponse) render(c *gin.Context, status int, resp *Response) {
+	encoders := h.Encoders
+	if encoders == nil {
+		encoders = DefaultEncoderRegistry()
+	}
+
+	// fall back to the next acceptable encoder if the response can not be encoded, such as a map in XML
+	for _, encoder := range encoders.acceptable(c.GetHeader("Accept")) {
+		var buf bytes.Buffer
+		if err := encoder.Encode(c, &buf, resp); err != nil {
+			log.WithFields(log.Fields{"url": c.Request.URL, "type": encoder.ContentType(), "err": err}).Warn("encode response")
+			continue
+		}
+
+		c.Data(status, encoder.ContentType(), buf.Bytes())
+		c.Abort()
+		return
+	}
+
+	msg := fmt.Sprintf("not acceptable, available types: %s", strings.Join(encoders.MediaTypes(), ", "))
+	c.Data(http.StatusNotAcceptable, binding.MIMEPlain, []byte(msg))
+	c.Abort()
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/protobuf/ptypes/wrappers"
+)
+
+func TestNegotiatedResponse(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetResponseAdaptor(&NegotiatedResponse{})
+	engine := gin.New()
+	engine.GET("/hello", h.HandleMiddleware(func(c *gin.Context) (string, error) { return "bytom", nil }))
+
+	tests := []struct {
+		name            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{name: "no accept", accept: "", wantStatus: 200, wantContentType: "application/json", wantBody: `{"code":200,"msg":"","data":"bytom"}`},
+		{name: "xml", accept: "application/xml", wantStatus: 200, wantContentType: "application/xml", wantBody: "<response><code>200</code><msg></msg><data>bytom</data></response>"},
+		{name: "quality", accept: "application/json;q=0.5, application/msgpack", wantStatus: 200, wantContentType: "application/msgpack"},
+		{name: "wildcard", accept: "text/html, */*;q=0.1", wantStatus: 200, wantContentType: "application/json"},
+		{name: "not acceptable", accept: "text/html", wantStatus: http.StatusNotAcceptable, wantContentType: "text/plain"},
+		{name: "excluded", accept: "application/*, application/json;q=0", wantStatus: 200, wantContentType: "application/xml; charset=utf-8"},
+		{name: "all excluded", accept: "*/*;q=0.5, application/*;q=0, text/*;q=0", wantStatus: http.StatusNotAcceptable, wantContentType: "text/plain"},
+		{name: "header order", accept: "application/msgpack, application/json", wantStatus: 200, wantContentType: "application/msgpack"},
+		{name: "specific over wildcard", accept: "*/*, application/json;q=0.5", wantStatus: 200, wantContentType: "application/xml; charset=utf-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/hello", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.wantContentType) {
+				t.Fatalf("status = %d content type = %s", w.Code, w.Header().Get("Content-Type"))
+			}
+			if tt.wantBody != "" && strings.TrimSpace(w.Body.String()) != tt.wantBody {
+				t.Errorf("body = %s, want %s", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestNegotiatedResponseFallback(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetResponseAdaptor(&NegotiatedResponse{})
+	engine := gin.New()
+	engine.GET("/map", h.HandleMiddleware(func(c *gin.Context) (map[string]int, error) { return map[string]int{"height": 1}, nil }))
+	engine.GET("/proto", h.HandleMiddleware(func(c *gin.Context) (*wrappers.StringValue, error) {
+		return &wrappers.StringValue{Value: "bytom"}, nil
+	}))
+
+	tests := []struct {
+		name            string
+		path            string
+		accept          string
+		wantStatus      int
+		wantContentType string
+	}{
+		{name: "map in xml only", path: "/map", accept: "application/xml", wantStatus: http.StatusNotAcceptable, wantContentType: "text/plain"},
+		{name: "map falls back to json", path: "/map", accept: "application/xml, application/json;q=0.5", wantStatus: 200, wantContentType: "application/json"},
+		{name: "protobuf", path: "/proto", accept: "application/x-protobuf", wantStatus: 200, wantContentType: "application/x-protobuf"},
+		{name: "not a protobuf message", path: "/map", accept: "application/x-protobuf", wantStatus: http.StatusNotAcceptable, wantContentType: "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus || !strings.HasPrefix(w.Header().Get("Content-Type"), tt.wantContentType) {
+				t.Fatalf("status = %d content type = %s, body = %s", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+			}
+			if tt.wantStatus == http.StatusNotAcceptable && w.Header().Get(HeaderResponseCode) != "" {
+				t.Errorf("the header of the failed protobuf encoding leaks into the response")
+			}
+		})
+	}
+}
@@ -2,17 +2,16 @@ package handler
 
 import (
 	"fmt"
+	"math"
 	"strconv"
-	"strings"
 
 	"github.com/bytom/bytom/errors"
 	"github.com/gin-gonic/gin"
 )
 
 const (
-	defaultStartStr = "0"
-	defaultLimitStr = "10"
-	maxPageLimit    = 1000
+	defaultPageLimit = 10
+	maxPageLimit     = 1000
 )
 
 var (
@@ -20,6 +19,103 @@ var (
 	errParsePaginationLimit = fmt.Errorf("parse pagination limit")
 )
 
+// PaginationStyle represent the names and meaning of the pagination query params
+type PaginationStyle int
+
+const (
+	// StartLimitStyle use the zero-based item index "start" and the page size "limit"
+	StartLimitStyle PaginationStyle = iota
+	// OffsetLimitStyle use the zero-based item index "offset" and the page size "limit"
+	OffsetLimitStyle
+	// PageStyle use the one-based page number "page" and the page size "page_size"
+	PageStyle
+)
+
+// Params return the names of the start param and the limit param
+func (s PaginationStyle) Params() (string, string) {
+	switch s {
+	case OffsetLimitStyle:
+		return "offset", "limit"
+	case PageStyle:
+		return "page", "page_size"
+	default:
+		return "start", "limit"
+	}
+}
+
+// startValue return the value of the start param of the page begins at start
+func (s PaginationStyle) startValue(start, limit uint64) uint64 {
+	if s != PageStyle {
+		return start
+	}
+
+	if limit == 0 {
+		return 1
+	}
+	return start/limit + 1
+}
+
+// PaginationOptions is the options of parsing the pagination query, the zero DefaultLimit and MaxLimit
+// are taken from DefaultPaginationOptions
+type PaginationOptions struct {
+	DefaultLimit uint64
+	MaxLimit     uint64
+	// RejectOversize respond a validation error instead of clamping the limit to MaxLimit
+	RejectOversize bool
+	Style          PaginationStyle
+}
+
+// DefaultPaginationOptions is the pagination options used if the handler does not set
+var DefaultPaginationOptions = PaginationOptions{
+	DefaultLimit: defaultPageLimit,
+	MaxLimit:     maxPageLimit,
+	Style:        StartLimitStyle,
+}
+
+// withDefaults return the options whose zero limits are filled from DefaultPaginationOptions
+func (o PaginationOptions) withDefaults() PaginationOptions {
+	if o.DefaultLimit == 0 {
+		o.DefaultLimit = DefaultPaginationOptions.DefaultLimit
+	}
+	if o.MaxLimit == 0 {
+		o.MaxLimit = DefaultPaginationOptions.MaxLimit
+	}
+	return o
+}
+
+// parseLimit parse the page size by the options
+func (o PaginationOptions) parseLimit(c *gin.Context, limitParam string) (uint64, error) {
+	limitStr, ok := c.GetQuery(limitParam)
+	if !ok {
+		return o.DefaultLimit, nil
+	}
+
+	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(paginationParamError(limitParam, "type", "", limitParam+" must be a non-negative integer"), errParsePaginationLimit)
+	}
+
+	if limit > o.MaxLimit {
+		if o.RejectOversize {
+			maxLimit := strconv.FormatUint(o.MaxLimit, 10)
+			return 0, errors.Wrap(paginationParamError(limitParam, "max", maxLimit, validationMessage(limitParam, "max", maxLimit)), errParsePaginationLimit)
+		}
+		limit = o.MaxLimit
+	}
+	return limit, nil
+}
+
+func paginationParamError(param, rule, ruleParam, message string) *ValidationError {
+	return &ValidationError{Fields: []FieldError{{
+		Field:   param,
+		Path:    param,
+		Source:  SourceQuery,
+		Rule:    rule,
+		Param:   ruleParam,
+		Message: message,
+	}}}
+}
+
 // PaginationResult used to return the pagination info
 type PaginationResult struct {
 	data  interface{}
@@ -33,9 +129,11 @@ func NewPaginationResult(data interface{}, total uint64) *PaginationResult {
 
 // PaginationQuery represent an argument of pagination query
 type Pagination struct {
-	Start uint64 `json:"start"`
-	Limit uint64 `json:"limit"`
-	Total uint64 `json:"total,omitempty"`
+	Start uint64 `json:"start" xml:"start"`
+	Limit uint64 `json:"limit" xml:"limit"`
+	Total uint64 `json:"total,omitempty" xml:"total,omitempty"`
+
+	style PaginationStyle
 }
 
 // PaginationQuery is the conditions for paging query
@@ -44,37 +142,53 @@ type PaginationQuery Pagination
 // PaginationResp is the response struct to pagination datas
 type PaginationResp struct {
 	*Pagination
-	Links links `json:"_links"`
-}
-
-type links struct {
-	Next string `json:"next,omitempty"`
-	Prev string `json:"prev,omitempty"`
+	Cursor *CursorPagination `json:"cursor,omitempty" xml:"cursor,omitempty"`
+	Links  links             `json:"_links" xml:"links"`
 }
 
 // ParsePagination request meets the standard on https://developer.atlassian.com/server/confluence/pagination-in-the-rest-api/
 func ParsePagination(c *gin.Context) (*PaginationQuery, error) {
-	startStr := c.DefaultQuery("start", defaultStartStr)
-	limitStr := c.DefaultQuery("limit", defaultLimitStr)
+	return ParsePaginationWithOptions(c, DefaultPaginationOptions)
+}
 
-	start, err := strconv.ParseUint(startStr, 10, 64)
+// ParsePaginationWithOptions parse the pagination query by the options, the malformed params are
+// responded as ValidationError
+func ParsePaginationWithOptions(c *gin.Context, opts PaginationOptions) (*PaginationQuery, error) {
+	opts = opts.withDefaults()
+	startParam, limitParam := opts.Style.Params()
+	limit, err := opts.parseLimit(c, limitParam)
 	if err != nil {
-		return nil, errors.Wrap(err, errParsePaginationStart)
+		return nil, err
 	}
 
-	limit, err := strconv.ParseUint(limitStr, 10, 64)
+	query := &PaginationQuery{Limit: limit, style: opts.Style}
+	startStr, ok := c.GetQuery(startParam)
+	if !ok {
+		return query, nil
+	}
+
+	start, err := strconv.ParseUint(startStr, 10, 64)
 	if err != nil {
-		return nil, errors.Wrap(err, errParsePaginationLimit)
+		return nil, errors.Wrap(paginationParamError(startParam, "type", "", startParam+" must be a non-negative integer"), errParsePaginationStart)
 	}
 
-	if limit > maxPageLimit {
-		limit = maxPageLimit
+	// the end of the page start+limit must not overflow
+	if opts.Style == PageStyle {
+		if start == 0 {
+			return nil, errors.Wrap(paginationParamError(startParam, "min", "1", validationMessage(startParam, "min", "1")), errParsePaginationStart)
+		}
+		if limit != 0 && start > math.MaxUint64/limit {
+			maxPage := strconv.FormatUint(math.MaxUint64/limit, 10)
+			return nil, errors.Wrap(paginationParamError(startParam, "max", maxPage, validationMessage(startParam, "max", maxPage)), errParsePaginationStart)
+		}
+		start = (start - 1) * limit
+	} else if start > math.MaxUint64-limit {
+		maxStart := strconv.FormatUint(math.MaxUint64-limit, 10)
+		return nil, errors.Wrap(paginationParamError(startParam, "max", maxStart, validationMessage(startParam, "max", maxStart)), errParsePaginationStart)
 	}
 
-	return &PaginationQuery{
-		Start: start,
-		Limit: limit,
-	}, nil
+	query.Start = start
+	return query, nil
 }
 
 // PaginationProcessor is the middle result of paging query
@@ -91,6 +205,7 @@ func NewPaginationProcessor(query *PaginationQuery, total uint64) *PaginationPro
 			Start: query.Start,
 			Limit: query.Limit,
 			Total: total,
+			style: query.style,
 		},
 		HasNext: total >= query.Limit+query.Start,
 		HasPrev: 0 != int(query.Start),
@@ -98,26 +213,30 @@ func NewPaginationProcessor(query *PaginationQuery, total uint64) *PaginationPro
 }
 
 // getLinks return the calculated PaginationProcessor links
-func (p *PaginationProcessor) getLinks(baseURL string) links {
-	l := links{}
+func (p *PaginationProcessor) getLinks(link linkFunc) links {
+	startParam, limitParam := p.style.Params()
+	page := func(start uint64) string {
+		return link(map[string]string{
+			limitParam: strconv.FormatUint(p.Limit, 10),
+			startParam: strconv.FormatUint(p.style.startValue(start, p.Limit), 10),
+		})
+	}
+
+	l := links{Self: page(p.Start), First: page(0)}
 	if p.HasNext {
-		// To efficiently build a string using Write methods
-		// https://stackoverflow.com/questions/1760757/how-to-efficiently-concatenate-strings-in-go
-		// https://tip.golang.org/pkg/strings/#Builder
-		var b strings.Builder
-		fmt.Fprintf(&b, "%s?limit=%d&start=%d", baseURL, p.Limit, p.Start+p.Limit)
-		l.Next = b.String()
+		l.Next = page(p.Start + p.Limit)
 	}
 
 	if p.HasPrev {
-		var b strings.Builder
-		prevStart := p.Start - p.Limit
-		if prevStart < 0 || p.Start < p.Limit {
-			prevStart = 0
+		prevStart := uint64(0)
+		if p.Start > p.Limit {
+			prevStart = p.Start - p.Limit
 		}
-		fmt.Fprintf(&b, "%s?limit=%d&start=%d", baseURL, p.Limit, prevStart)
-		l.Prev = b.String()
+		l.Prev = page(prevStart)
 	}
 
+	if p.Limit > 0 && p.Total > 0 {
+		l.Last = page((p.Total - 1) / p.Limit * p.Limit)
+	}
 	return l
 }
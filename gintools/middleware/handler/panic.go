@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/bytom/community/errors"
+)
+
+// panicStackSize is the max number of the stack frames captured from a panic
+const panicStackSize = 32
+
+// PanicError is the error recovered from a panic of the handler function. Its message is generic,
+// so the panic value is never exposed in the response, the value and the stack are logged instead.
+type PanicError struct {
+	Value interface{}
+	Stack []errors.StackFrame
+}
+
+func (e *PanicError) Error() string {
+	return "internal server error"
+}
+
+// SetPanicErrCode set the error code responded with HTTP 500 when the handler function panics
+func (h *Handler) SetPanicErrCode(code int) *Handler {
+	h.panicErrCode = code
+	return h
+}
+
+// recoverPanic recover the panic of the request, log it with the stack, and respond the PanicError
+// unless the response has been written. It must be deferred directly. A recovered *PanicError is
+// the panic forwarded from the goroutine of the handler function, which already holds its stack.
+func (h *Handler) recoverPanic(context *gin.Context) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	// http.ErrAbortHandler is the way to abort the response silently
+	if r == http.ErrAbortHandler {
+		panic(r)
+	}
+
+	err, ok := r.(*PanicError)
+	if !ok {
+		err = newPanicError(r)
+	}
+
+	log.WithFields(log.Fields{
+		"url":     context.Request.URL,
+		"request": context.Value(ReqBodyLabel),
+		"panic":   fmt.Sprint(err.Value),
+		"stack":   err.Stack,
+	}).Error("handler panic")
+
+	if context.Writer.Written() {
+		context.Abort()
+		return
+	}
+
+	h.respondErrorSpec(context, err, h.panicSpec(err))
+}
+
+// panicSpec resolve the ErrorSpec of the PanicError, which defaults to HTTP 500 with the panic error code
+func (h *Handler) panicSpec(err *PanicError) ErrorSpec {
+	spec, ok := h.errorRegistry.Resolve(err)
+	if !ok {
+		spec = ErrorSpec{Code: h.panicErrCode}
+	}
+	if spec.HTTPStatus == 0 {
+		spec.HTTPStatus = http.StatusInternalServerError
+	}
+	return spec
+}
+
+// newPanicError capture the stack of the panic, it must be called by the deferred function which recovered
+func newPanicError(r interface{}) *PanicError {
+	// skip newPanicError, the deferred function and the runtime frames of the panic
+	stack := errors.Callers(2, panicStackSize)
+	for len(stack) > 0 && isRuntimeFrame(stack[0]) {
+		stack = stack[1:]
+	}
+	return &PanicError{Value: r, Stack: stack}
+}
+
+func isRuntimeFrame(frame errors.StackFrame) bool {
+	return strings.HasPrefix(frame.Func, "runtime.")
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	var recovered *PanicError
+	h := NewHandler(nil, nil, nil).SetResponseAdaptor(&StatusResponse{}).SetPanicErrCode(50000)
+	h.ErrorRegistry().RegisterFunc(func(err error) bool {
+		recovered, _ = err.(*PanicError)
+		return false
+	}, ErrorSpec{})
+
+	engine := gin.New()
+	engine.GET("/panic", h.HandleMiddleware(func(c *gin.Context) (string, error) {
+		var m map[string]int
+		m["boom"]++
+		return "unreachable", nil
+	}))
+	engine.GET("/typed", JSON(h, func(c *gin.Context, req *Empty) (string, error) {
+		panic("typed boom")
+	}))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), `"code":50000`) {
+		t.Errorf("response = %d %s, want 500 with the panic code", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "nil map") {
+		t.Errorf("response = %s, want the panic value not exposed", w.Body.String())
+	}
+
+	if recovered == nil || len(recovered.Stack) == 0 {
+		t.Fatalf("recovered = %v, want the PanicError with stack", recovered)
+	}
+	if !strings.Contains(recovered.Stack[0].Func, "TestRecoverPanic") {
+		t.Errorf("first stack frame = %v, want the panicking function", recovered.Stack[0])
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/typed", nil))
+	if w.Code != http.StatusInternalServerError || recovered.Value != "typed boom" {
+		t.Errorf("response = %d, recovered = %v, want the panic of the typed handler recovered", w.Code, recovered.Value)
+	}
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// MIMEProblemJSON is the content type of the problem details
+const MIMEProblemJSON = "application/problem+json"
+
+const problemTypeBlank = "about:blank"
+
+// Problem describes the problem details of RFC 7807, the extension members are marshaled along with the standard ones
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]interface{}
+}
+
+// MarshalJSON marshal the problem with its extension members, which never override the standard members
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for key, value := range p.Extensions {
+		members[key] = value
+	}
+
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+// ProblemResponse respond the error as the problem details, and the success as StandardResponse
+type ProblemResponse struct {
+	StandardResponse
+
+	// TypeBaseURI is prefixed to the error code to build the problem type, "about:blank" is used if it is empty
+	TypeBaseURI string
+}
+
+// RespondErrorResp return the problem details with the default HTTP status
+func (h *ProblemResponse) RespondErrorResp(c *gin.Context, err error, errCode int) {
+	h.RespondErrorSpec(c, err, ErrorSpec{HTTPStatus: defaultErrorStatus(errCode), Code: errCode})
+}
+
+// RespondErrorSpec return the problem details built from the error and its spec
+func (h *ProblemResponse) RespondErrorSpec(c *gin.Context, err error, spec ErrorSpec) {
+	log.WithFields(log.Fields{
+		"url":     c.Request.URL,
+		"request": c.Value(ReqBodyLabel),
+		"err":     err,
+	}).Error("respond error")
+
+	body, marshalErr := json.Marshal(h.formatProblem(c, err, spec))
+	if marshalErr != nil {
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(spec.HTTPStatus, MIMEProblemJSON, body)
+	c.Abort()
+}
+
+// formatProblem build the problem details, the detail of unmapped errors are hidden from the client
+func (h *ProblemResponse) formatProblem(c *gin.Context, err error, spec ErrorSpec) *Problem {
+	problem := &Problem{
+		Type:       problemTypeBlank,
+		Title:      http.StatusText(spec.HTTPStatus),
+		Status:     spec.HTTPStatus,
+		Instance:   c.Request.URL.Path,
+		Extensions: make(map[string]interface{}),
+	}
+
+	if spec.Code == 0 {
+		return problem
+	}
+
+	root := errors.Root(err)
+	if h.TypeBaseURI != "" {
+		problem.Type = h.TypeBaseURI + strconv.Itoa(spec.Code)
+		problem.Title = root.Error()
+		if spec.Message != "" {
+			problem.Title = spec.Message
+		}
+	}
+
+	detail := errors.Detail(err)
+	if detail == "" {
+		detail = root.Error()
+	}
+	if detail != problem.Title {
+		problem.Detail = detail
+	}
+
+	for key, value := range errors.Data(err) {
+		problem.Extensions[key] = value
+	}
+	problem.Extensions["code"] = spec.Code
+
+	if validationErr, ok := root.(*ValidationError); ok {
+		problem.Extensions["errors"] = validationErr.Fields
+	}
+	return problem
+}
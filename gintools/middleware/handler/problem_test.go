@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+func TestProblemResponse(t *testing.T) {
+	errInsufficient := errors.New("insufficient balance")
+	h := NewHandlerWithErrorSpecs(map[error]ErrorSpec{
+		errInsufficient: {HTTPStatus: http.StatusConflict, Code: 1001},
+	}, nil, nil).SetResponseAdaptor(&ProblemResponse{TypeBaseURI: "https://errors.bytom.io/"})
+
+	engine := gin.New()
+	engine.POST("/transfer", h.HandleMiddleware(func(c *gin.Context) error {
+		err := errors.WithDetail(errInsufficient, "need 10 more BTM")
+		return errors.WithData(err, "asset", "BTM")
+	}))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/transfer", nil))
+	if w.Code != http.StatusConflict || w.Header().Get("Content-Type") != MIMEProblemJSON {
+		t.Fatalf("status = %d content type = %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{
+		"type":     "https://errors.bytom.io/1001",
+		"title":    "insufficient balance",
+		"status":   float64(409),
+		"detail":   "need 10 more BTM",
+		"instance": "/transfer",
+		"code":     float64(1001),
+		"asset":    "BTM",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("problem = %v, want %v", got, want)
+	}
+}
@@ -1,31 +1,155 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
-	errMissingFilterKey  = errors.New("missing filter key")
-	errInvalidFilterType = errors.New("invalid filter type")
+	// ErrMissingFilterKey is the cause of FilterError when the filter key is absent
+	ErrMissingFilterKey = errors.New("missing filter key")
+	// ErrInvalidFilterType is the cause of FilterError when the filter value is not of the expected type
+	ErrInvalidFilterType = errors.New("invalid filter type")
 )
 
+// FilterError is returned by the filter accessors, Err is ErrMissingFilterKey or ErrInvalidFilterType
+type FilterError struct {
+	Key      string
+	Expected string
+	Value    interface{}
+	Err      error
+}
+
+func (e *FilterError) Error() string {
+	if e.Err == ErrMissingFilterKey {
+		return fmt.Sprintf("%v %q", e.Err, e.Key)
+	}
+	return fmt.Sprintf("%v: filter %q expected %s, got %s", e.Err, e.Key, e.Expected, describeFilterValue(e.Value))
+}
+
+// Unwrap return the cause of the error
+func (e *FilterError) Unwrap() error {
+	return e.Err
+}
+
+func describeFilterValue(val interface{}) string {
+	switch v := val.(type) {
+	case nil:
+		return "null"
+	case json.Number:
+		return "number " + v.String()
+	case string:
+		return "string " + strconv.Quote(v)
+	}
+	return fmt.Sprintf("%T %v", val, val)
+}
+
+// Filters is the display filter, the numbers decoded from JSON are json.Number to keep their precision.
+// It was map[string]interface{} of float64 numbers before, so a type assertion such as
+// d.Filter["amount"].(float64) no longer matches, use the typed accessors such as GetFilterNum,
+// GetFilterInt64, GetFilterUint64, GetFilterDecimal or GetFilter[float64] instead.
+type Filters map[string]interface{}
+
+// UnmarshalJSON decode the filters with json.Number
+func (f *Filters) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var filters map[string]interface{}
+	if err := decoder.Decode(&filters); err != nil {
+		return err
+	}
+
+	*f = filters
+	return nil
+}
+
 // DisplayAble used to determine whether a request contains display sort
 type DisplayAble interface {
 	GetOrder() string
 	SetOrder(string)
 }
 
+// SortAble used to determine whether a request contains multi-key display sort
+type SortAble interface {
+	GetSortKeys() []SortKey
+	SetSortKeys([]SortKey)
+}
+
+// the sort directions
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// the positions of the null values in sorting
+const (
+	NullsFirst = "first"
+	NullsLast  = "last"
+)
+
 // Display represent a request supports display filtering and sorting
 type Display struct {
-	Filter map[string]interface{} `json:"filter"`
-	Sorter Sorter                 `json:"sort"`
+	Filter Filters `json:"filter"`
+	Sorter Sorter  `json:"sort"`
 }
 
-// Sorter represent a request supports display sorting
+// Sorter represent a request supports display sorting, the Keys take precedence over By and Order.
+// Besides the object form, it can be decoded from an array of the sort keys, such as
+// [{"by": "amount", "order": "desc", "nulls": "last"}, {"by": "id"}].
 type Sorter struct {
+	By    string    `json:"by"`
+	Order string    `json:"order"`
+	Keys  []SortKey `json:"keys,omitempty"`
+}
+
+// SortKey represent a field of the multi-key sorting
+type SortKey struct {
 	By    string `json:"by"`
 	Order string `json:"order"`
+	// Nulls is the position of the null values, which is NullsFirst or NullsLast, the storage default is used if it is empty
+	Nulls string `json:"nulls,omitempty"`
+}
+
+// UnmarshalJSON decode the sorter from either the object form or the array of sort keys
+func (s *Sorter) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && trimmed[0] == '[' {
+		*s = Sorter{}
+		return json.Unmarshal(trimmed, &s.Keys)
+	}
+
+	type sorter Sorter
+	return json.Unmarshal(data, (*sorter)(s))
+}
+
+// SortKeys return the sort keys in order, By and Order is the only key if Keys is empty
+func (s Sorter) SortKeys() []SortKey {
+	if len(s.Keys) != 0 {
+		return s.Keys
+	}
+
+	if s.By == "" {
+		return nil
+	}
+	return []SortKey{{By: s.By, Order: s.Order}}
+}
+
+// NormalizeSortDirection normalize the sort direction to SortAsc or SortDesc, "asc", "ascending" and "1"
+// are ascending, "desc", "descending" and "-1" are descending, the empty direction is ascending
+func NormalizeSortDirection(order string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(order)) {
+	case "", SortAsc, "ascending", "1", "+1":
+		return SortAsc, true
+	case SortDesc, "descending", "-1":
+		return SortDesc, true
+	}
+	return "", false
 }
 
 // GetOrder return sort's order
@@ -38,51 +162,278 @@ func (d *Display) SetOrder(order string) {
 	d.Sorter.Order = order
 }
 
+// GetSortKeys return the sort keys in order
+func (d *Display) GetSortKeys() []SortKey {
+	return d.Sorter.SortKeys()
+}
+
+// SetSortKeys set the sort keys
+func (d *Display) SetSortKeys(keys []SortKey) {
+	d.Sorter.Keys = keys
+}
+
+// filterValue return the value of the filter key, or FilterError if it is missing
+func (d *Display) filterValue(filterKey, expected string) (interface{}, error) {
+	val, ok := d.Filter[filterKey]
+	if !ok {
+		return nil, &FilterError{Key: filterKey, Expected: expected, Err: ErrMissingFilterKey}
+	}
+	return val, nil
+}
+
+func invalidFilter(filterKey, expected string, val interface{}) error {
+	return &FilterError{Key: filterKey, Expected: expected, Value: val, Err: ErrInvalidFilterType}
+}
+
 // GetFilterString give the filter keyword return the string value
 func (d *Display) GetFilterString(filterKey string) (string, error) {
-	if _, ok := d.Filter[filterKey]; !ok {
-		return "", errMissingFilterKey
+	val, err := d.filterValue(filterKey, "string")
+	if err != nil {
+		return "", err
 	}
-	if val, ok := d.Filter[filterKey].(string); ok {
-		return val, nil
+	if s, ok := val.(string); ok {
+		return s, nil
 	}
-	return "", errInvalidFilterType
+	return "", invalidFilter(filterKey, "string", val)
 }
 
-// GetFilterNum give the filter keyword return the numeric value
+// GetFilterNum give the filter keyword return the numeric value, the decoded JSON number is returned as float64
 func (d *Display) GetFilterNum(filterKey string) (interface{}, error) {
-	if _, ok := d.Filter[filterKey]; !ok {
-		return 0, errMissingFilterKey
+	val, err := d.filterValue(filterKey, "number")
+	if err != nil {
+		return 0, err
 	}
-	switch val := d.Filter[filterKey].(type) {
+	switch num := val.(type) {
+	case json.Number:
+		if f, err := num.Float64(); err == nil {
+			return f, nil
+		}
 	case int, int16, int32, int64, int8, uint, uint16, uint32, uint64, uint8, float32, float64:
-		return val, nil
+		return num, nil
 	}
 
-	return 0, errInvalidFilterType
+	return 0, invalidFilter(filterKey, "number", val)
 }
 
 // GetFilterBoolean give the filter keyword return the boolean value
 func (d *Display) GetFilterBoolean(filterKey string) (bool, error) {
-	if _, ok := d.Filter[filterKey]; !ok {
-		return false, errMissingFilterKey
+	val, err := d.filterValue(filterKey, "boolean")
+	if err != nil {
+		return false, err
 	}
-	if val, ok := d.Filter[filterKey].(bool); ok {
-		return val, nil
+	if b, ok := val.(bool); ok {
+		return b, nil
 	}
-	return false, errInvalidFilterType
+	return false, invalidFilter(filterKey, "boolean", val)
 }
 
 // GetFilterObject give the filter keyword return the object value
 func (d *Display) GetFilterObject(filterKey string, obj interface{}) error {
-	if _, ok := d.Filter[filterKey]; !ok {
-		return errMissingFilterKey
+	val, err := d.filterValue(filterKey, "object")
+	if err != nil {
+		return err
 	}
 
-	bytes, err := json.Marshal(d.Filter[filterKey])
+	bytes, err := json.Marshal(val)
 	if err != nil {
 		return err
 	}
 
 	return json.Unmarshal(bytes, obj)
 }
+
+// GetFilterInt64 give the filter keyword return the int64 value, the float value must be integral
+func (d *Display) GetFilterInt64(filterKey string) (int64, error) {
+	val, err := d.filterValue(filterKey, "int64")
+	if err != nil {
+		return 0, err
+	}
+
+	switch num := val.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(num.String(), 10, 64); err == nil {
+			return i, nil
+		}
+	case float64:
+		if num == math.Trunc(num) && num >= math.MinInt64 && num < math.MaxInt64 {
+			return int64(num), nil
+		}
+	case int:
+		return int64(num), nil
+	case int64:
+		return num, nil
+	case int32:
+		return int64(num), nil
+	case uint32:
+		return int64(num), nil
+	case uint64:
+		if num <= math.MaxInt64 {
+			return int64(num), nil
+		}
+	}
+	return 0, invalidFilter(filterKey, "int64", val)
+}
+
+// GetFilterUint64 give the filter keyword return the uint64 value, the decoded JSON number keeps its precision
+// up to the max uint64
+func (d *Display) GetFilterUint64(filterKey string) (uint64, error) {
+	val, err := d.filterValue(filterKey, "uint64")
+	if err != nil {
+		return 0, err
+	}
+
+	switch num := val.(type) {
+	case json.Number:
+		if u, err := strconv.ParseUint(num.String(), 10, 64); err == nil {
+			return u, nil
+		}
+	case float64:
+		if num == math.Trunc(num) && num >= 0 && num < math.MaxUint64 {
+			return uint64(num), nil
+		}
+	case uint64:
+		return num, nil
+	case uint:
+		return uint64(num), nil
+	case uint32:
+		return uint64(num), nil
+	case int:
+		if num >= 0 {
+			return uint64(num), nil
+		}
+	case int64:
+		if num >= 0 {
+			return uint64(num), nil
+		}
+	}
+	return 0, invalidFilter(filterKey, "uint64", val)
+}
+
+// GetFilterDecimal give the filter keyword return the exact decimal value, the value is either a number or
+// a numeric string, such as 0.1 or "12.345"
+func (d *Display) GetFilterDecimal(filterKey string) (*big.Rat, error) {
+	val, err := d.filterValue(filterKey, "decimal")
+	if err != nil {
+		return nil, err
+	}
+
+	switch num := val.(type) {
+	case json.Number:
+		if r, ok := new(big.Rat).SetString(num.String()); ok {
+			return r, nil
+		}
+	case string:
+		if r, ok := new(big.Rat).SetString(num); ok {
+			return r, nil
+		}
+	case float64:
+		if r := new(big.Rat).SetFloat64(num); r != nil {
+			return r, nil
+		}
+	case int:
+		return new(big.Rat).SetInt64(int64(num)), nil
+	case int64:
+		return new(big.Rat).SetInt64(num), nil
+	case uint64:
+		return new(big.Rat).SetUint64(num), nil
+	}
+	return nil, invalidFilter(filterKey, "decimal", val)
+}
+
+// GetFilterTime give the filter keyword return the time value, which is either a RFC3339 string or unix seconds
+func (d *Display) GetFilterTime(filterKey string) (time.Time, error) {
+	val, err := d.filterValue(filterKey, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if t, ok := parseFilterTime(val); ok {
+		return t, nil
+	}
+	return time.Time{}, invalidFilter(filterKey, "time", val)
+}
+
+// parseFilterTime parse the RFC3339 string or the integral unix seconds
+func parseFilterTime(val interface{}) (time.Time, bool) {
+	switch v := val.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+		return t, err == nil
+	}
+
+	if num, ok := normalizeNumber(val); ok {
+		if sec, isInt := num.(int64); isInt {
+			return time.Unix(sec, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// GetFilterStrings give the filter keyword return the string array value
+func (d *Display) GetFilterStrings(filterKey string) ([]string, error) {
+	val, err := d.filterValue(filterKey, "string array")
+	if err != nil {
+		return nil, err
+	}
+
+	switch list := val.(type) {
+	case []string:
+		return list, nil
+	case []interface{}:
+		strs := make([]string, len(list))
+		for i, item := range list {
+			s, ok := item.(string)
+			if !ok {
+				return nil, invalidFilter(filterKey, "string array", val)
+			}
+			strs[i] = s
+		}
+		return strs, nil
+	}
+	return nil, invalidFilter(filterKey, "string array", val)
+}
+
+// GetFilter give the filter keyword return the value of type T, the scalar types are read by the typed accessors
+// of Display, and the others are decoded by GetFilterObject
+func GetFilter[T any](d *Display, filterKey string) (T, error) {
+	var (
+		result T
+		val    interface{}
+		err    error
+	)
+	switch any(result).(type) {
+	case string:
+		val, err = d.GetFilterString(filterKey)
+	case bool:
+		val, err = d.GetFilterBoolean(filterKey)
+	case int64:
+		val, err = d.GetFilterInt64(filterKey)
+	case uint64:
+		val, err = d.GetFilterUint64(filterKey)
+	case float64:
+		var num interface{}
+		if num, err = d.GetFilterNum(filterKey); err == nil {
+			val, _ = toFloat64(num)
+		}
+	case *big.Rat:
+		val, err = d.GetFilterDecimal(filterKey)
+	case time.Time:
+		val, err = d.GetFilterTime(filterKey)
+	case []string:
+		val, err = d.GetFilterStrings(filterKey)
+	default:
+		if err = d.GetFilterObject(filterKey, &result); err != nil {
+			if _, ok := err.(*FilterError); !ok {
+				err = &FilterError{Key: filterKey, Expected: fmt.Sprintf("%T", result), Value: d.Filter[filterKey], Err: ErrInvalidFilterType}
+			}
+		}
+		return result, err
+	}
+
+	if err != nil {
+		return result, err
+	}
+	return val.(T), nil
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const accessorDisplay = `{"filter":{
+	"amount": 18446744073709551615,
+	"fee": -12,
+	"rate": 0.1,
+	"price": "12.345",
+	"since": "2020-01-02T03:04:05Z",
+	"until": 1577934245,
+	"assets": ["BTM", "BTC"],
+	"mixed": ["BTM", 1],
+	"memo": "pay",
+	"range": {"min": 1, "max": 2}
+}}`
+
+func TestFilterAccessors(t *testing.T) {
+	d := parseDisplay(t, accessorDisplay)
+
+	if amount, err := d.GetFilterUint64("amount"); err != nil || amount != 18446744073709551615 {
+		t.Errorf("GetFilterUint64() = %d, %v, want the max uint64 without precision loss", amount, err)
+	}
+	if fee, err := d.GetFilterInt64("fee"); err != nil || fee != -12 {
+		t.Errorf("GetFilterInt64() = %d, %v, want -12", fee, err)
+	}
+	if rate, err := d.GetFilterDecimal("rate"); err != nil || rate.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Errorf("GetFilterDecimal(rate) = %v, %v, want exactly 1/10", rate, err)
+	}
+	if price, err := d.GetFilterDecimal("price"); err != nil || price.FloatString(3) != "12.345" {
+		t.Errorf("GetFilterDecimal(price) = %v, %v, want 12.345", price, err)
+	}
+
+	want := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if since, err := d.GetFilterTime("since"); err != nil || !since.Equal(want) {
+		t.Errorf("GetFilterTime(since) = %v, %v, want %v", since, err, want)
+	}
+	if until, err := d.GetFilterTime("until"); err != nil || !until.Equal(want) {
+		t.Errorf("GetFilterTime(until) = %v, %v, want %v", until, err, want)
+	}
+	if assets, err := d.GetFilterStrings("assets"); err != nil || !reflect.DeepEqual(assets, []string{"BTM", "BTC"}) {
+		t.Errorf("GetFilterStrings() = %v, %v, want [BTM BTC]", assets, err)
+	}
+	if num, err := d.GetFilterNum("rate"); err != nil || num != 0.1 {
+		t.Errorf("GetFilterNum() = %v, %v, want float64 0.1 for compatibility", num, err)
+	}
+}
+
+func TestFilterAccessorErrors(t *testing.T) {
+	d := parseDisplay(t, accessorDisplay)
+
+	_, err := d.GetFilterUint64("fee")
+	var filterErr *FilterError
+	if !errors.As(err, &filterErr) || filterErr.Key != "fee" || filterErr.Expected != "uint64" || !errors.Is(err, ErrInvalidFilterType) {
+		t.Fatalf("GetFilterUint64(fee) error = %v, want FilterError of invalid type", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"fee"`) || !strings.Contains(msg, "uint64") || !strings.Contains(msg, "number -12") {
+		t.Errorf("error message = %q, want the key, expected type and actual value", msg)
+	}
+
+	if _, err := d.GetFilterInt64("rate"); !errors.Is(err, ErrInvalidFilterType) {
+		t.Errorf("GetFilterInt64(rate) error = %v, want invalid type", err)
+	}
+	if _, err := d.GetFilterStrings("mixed"); !errors.Is(err, ErrInvalidFilterType) {
+		t.Errorf("GetFilterStrings(mixed) error = %v, want invalid type", err)
+	}
+	if _, err := d.GetFilterTime("memo"); !errors.Is(err, ErrInvalidFilterType) {
+		t.Errorf("GetFilterTime(memo) error = %v, want invalid type", err)
+	}
+	if _, err := d.GetFilterString("missing"); !errors.Is(err, ErrMissingFilterKey) {
+		t.Errorf("GetFilterString(missing) error = %v, want missing key", err)
+	}
+}
+
+func TestGetFilter(t *testing.T) {
+	d := parseDisplay(t, accessorDisplay)
+
+	if amount, err := GetFilter[uint64](d, "amount"); err != nil || amount != 18446744073709551615 {
+		t.Errorf("GetFilter[uint64]() = %d, %v", amount, err)
+	}
+	if memo, err := GetFilter[string](d, "memo"); err != nil || memo != "pay" {
+		t.Errorf("GetFilter[string]() = %q, %v", memo, err)
+	}
+	if rate, err := GetFilter[float64](d, "rate"); err != nil || rate != 0.1 {
+		t.Errorf("GetFilter[float64]() = %v, %v", rate, err)
+	}
+
+	type amountRange struct {
+		Min uint64 `json:"min"`
+		Max uint64 `json:"max"`
+	}
+	if r, err := GetFilter[amountRange](d, "range"); err != nil || r != (amountRange{Min: 1, Max: 2}) {
+		t.Errorf("GetFilter[amountRange]() = %+v, %v", r, err)
+	}
+	if _, err := GetFilter[amountRange](d, "memo"); !errors.Is(err, ErrInvalidFilterType) {
+		t.Errorf("GetFilter[amountRange](memo) error = %v, want invalid type", err)
+	}
+	if _, err := GetFilter[bool](d, "missing"); !errors.Is(err, ErrMissingFilterKey) {
+		t.Errorf("GetFilter[bool](missing) error = %v, want missing key", err)
+	}
+}
@@ -1,9 +1,7 @@
 package handler
 
 import (
-	"fmt"
 	"net/http"
-	"strings"
 
 	"github.com/bytom/bytom/errors"
 	"github.com/gin-gonic/gin"
@@ -17,17 +15,27 @@ type ResponseAdaptor interface {
 	RespondSuccessPaginationResp(c *gin.Context, data interface{}, paginationProcessor *PaginationProcessor)
 }
 
+// CursorPaginationAdaptor is a ResponseAdaptor which is able to respond the cursor pagination,
+// the handler responds the data only by RespondSuccessResp if the adaptor does not implement it
+type CursorPaginationAdaptor interface {
+	ResponseAdaptor
+	RespondSuccessCursorResp(c *gin.Context, data interface{}, cursorProcessor *CursorProcessor)
+}
+
 // StandardResponse standard response
 type StandardResponse struct {
+	// Links build the pagination links, the links are relative to the host if it is nil
+	Links *LinkBuilder
 }
 
 // Response describes the response standard. Code & Msg are always present.
-// Data is present for a success response only.
+// Data is present for a success response only, Errors is present for a validation error response only.
 type Response struct {
-	Code       int             `json:"code"`
-	Msg        string          `json:"msg"`
-	Data       interface{}     `json:"data,omitempty"`
-	Pagination *PaginationResp `json:"pagination,omitempty"`
+	Code       int             `json:"code" xml:"code"`
+	Msg        string          `json:"msg" xml:"msg"`
+	Data       interface{}     `json:"data,omitempty" xml:"data,omitempty"`
+	Errors     []FieldError    `json:"errors,omitempty" xml:"error,omitempty"`
+	Pagination *PaginationResp `json:"pagination,omitempty" xml:"pagination,omitempty"`
 }
 
 // RespondErrorResp return error response
@@ -53,6 +61,10 @@ func (h *StandardResponse) formatErrResp(err error, code int) Response {
 		response.Code = code
 		response.Msg = root.Error()
 	}
+
+	if validationErr, ok := root.(*ValidationError); ok {
+		response.Errors = validationErr.Fields
+	}
 	return response
 }
 
@@ -63,17 +75,65 @@ func (h *StandardResponse) RespondSuccessResp(c *gin.Context, data interface{})
 
 // RespondSuccessPaginationResp return success response context of the pagination request
 func (h *StandardResponse) RespondSuccessPaginationResp(c *gin.Context, data interface{}, paginationProcessor *PaginationProcessor) {
-	url := fmt.Sprintf("%v", c.Request.URL)
-	base := strings.Split(url, "?")[0]
-	links := paginationProcessor.getLinks(base)
-	c.AbortWithStatusJSON(http.StatusOK, Response{
+	c.AbortWithStatusJSON(http.StatusOK, h.formatPaginationResp(c, data, paginationProcessor))
+}
+
+// formatPaginationResp build the success response with the pagination links, and set the Link header
+func (h *StandardResponse) formatPaginationResp(c *gin.Context, data interface{}, paginationProcessor *PaginationProcessor) Response {
+	startParam, limitParam := paginationProcessor.style.Params()
+	links := paginationProcessor.getLinks(h.Links.linker(c, startParam, limitParam))
+	c.Header("Link", links.header())
+	return Response{
 		Code: http.StatusOK,
 		Data: data,
 		Pagination: &PaginationResp{
 			Pagination: paginationProcessor.Pagination,
 			Links:      links,
 		},
-	})
+	}
+}
+
+// RespondSuccessCursorResp return success response context of the cursor pagination request
+func (h *StandardResponse) RespondSuccessCursorResp(c *gin.Context, data interface{}, cursorProcessor *CursorProcessor) {
+	c.AbortWithStatusJSON(http.StatusOK, h.formatCursorResp(c, data, cursorProcessor))
+}
+
+// formatCursorResp build the success response with the cursor pagination links, and set the Link header
+func (h *StandardResponse) formatCursorResp(c *gin.Context, data interface{}, cursorProcessor *CursorProcessor) Response {
+	links := cursorProcessor.getLinks(h.Links.linker(c, "cursor", "limit"))
+	c.Header("Link", links.header())
+	return Response{
+		Code: http.StatusOK,
+		Data: data,
+		Pagination: &PaginationResp{
+			Cursor: cursorProcessor.CursorPagination,
+			Links:  links,
+		},
+	}
+}
+
+// StatusResponse is a standard response which respond the error with the HTTP status of ErrorSpec
+type StatusResponse struct {
+	StandardResponse
+}
+
+// RespondErrorResp return error response with the default HTTP status
+func (h *StatusResponse) RespondErrorResp(c *gin.Context, err error, errCode int) {
+	h.RespondErrorSpec(c, err, ErrorSpec{HTTPStatus: defaultErrorStatus(errCode), Code: errCode})
+}
+
+// RespondErrorSpec return error response with the HTTP status and message of spec
+func (h *StatusResponse) RespondErrorSpec(c *gin.Context, err error, spec ErrorSpec) {
+	log.WithFields(log.Fields{
+		"url":     c.Request.URL,
+		"request": c.Value(ReqBodyLabel),
+		"err":     err,
+	}).Error("respond error")
+	response := h.formatErrResp(err, spec.Code)
+	if spec.Message != "" {
+		response.Msg = spec.Message
+	}
+	c.AbortWithStatusJSON(spec.HTTPStatus, response)
 }
 
 // SimpleResponse simple response
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"reflect"
+	"time"
+)
+
+// routeConfig is the configuration of a route, which is derived from the handler and the route options
+type routeConfig struct {
+	pagination PaginationOptions
+	timeout    time.Duration
+	stream     StreamOptions
+
+	// the description of the route recorded in the route registry
+	name     string
+	summary  string
+	tags     []string
+	response reflect.Type
+}
+
+// RouteOption override the configuration of the handler for a single route
+type RouteOption func(*routeConfig)
+
+// WithPagination override the pagination options of the route
+func WithPagination(opts PaginationOptions) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.pagination = opts
+	}
+}
+
+// WithPageLimit override the default and max page size of the route, zero means the one of DefaultPaginationOptions
+func WithPageLimit(defaultLimit, maxLimit uint64) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.pagination.DefaultLimit = defaultLimit
+		cfg.pagination.MaxLimit = maxLimit
+	}
+}
+
+// WithTimeout override the deadline of the handler function of the route, zero means no deadline
+func WithTimeout(timeout time.Duration) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.timeout = timeout
+	}
+}
+
+// WithName set the unique name of the route in the route registry, it defaults to the camel case of
+// the method and the path, such as getUsersById for GET /users/:id
+func WithName(name string) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.name = name
+	}
+}
+
+// WithSummary set the summary of the route in the route registry
+func WithSummary(summary string) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.summary = summary
+	}
+}
+
+// WithTags append the tags of the route in the route registry
+func WithTags(tags ...string) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.tags = append(cfg.tags, tags...)
+	}
+}
+
+// WithResponseType set the type of the response data in the route registry by an example value of it,
+// such as WithResponseType([]Order{}). It is required to describe the data of the pagination handler
+// functions wrapped by HandleMiddleware, whose data is returned as interface{}.
+func WithResponseType(example interface{}) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.response = reflect.TypeOf(example)
+	}
+}
+
+// routeConfig return the configuration of the route with the default route options of the handler and the options applied
+func (h *Handler) routeConfig(opts ...RouteOption) *routeConfig {
+	cfg := &routeConfig{pagination: h.pagination, timeout: h.timeout, stream: h.streamOptions}
+	for _, opt := range h.routeOptions {
+		opt(cfg)
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.pagination = cfg.pagination.withDefaults()
+	return cfg
+}
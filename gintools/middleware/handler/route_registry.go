@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// the kinds of pagination of the routes
+const (
+	PaginationOffset = "offset"
+	PaginationCursor = "cursor"
+)
+
+var displayGetterType = reflect.TypeOf((*DisplayGetter)(nil)).Elem()
+
+// RouteInfo describe a route registered by the route group, which is enough to document the API
+type RouteInfo struct {
+	Name    string
+	Method  string
+	Path    string
+	Summary string
+	Tags    []string
+
+	// Request is the struct type of the request argument, it is nil if the handler function has no argument
+	Request reflect.Type
+	// Response is the type of the response data, it is nil if it is unknown
+	Response reflect.Type
+	// Pagination is PaginationOffset or PaginationCursor if the route is paginated, and Response is the slice of the items
+	Pagination        string
+	PaginationOptions PaginationOptions
+	// Stream report whether the items of Response are streamed as Server-Sent Events or NDJSON
+	Stream bool
+	// Display report whether the request argument supports filtering and sorting by Display
+	Display bool
+	// Errors is the specs of the errors the route may respond, ordered by the error code, the HTTPStatus is zero
+	// if the error is registered without a status, which depends on the response adaptor
+	Errors []ErrorSpec
+
+	// Handle is the gin-compatible processing function of the route
+	Handle gin.HandlerFunc
+	// Handlers is the whole handler chain of the route, which is the middleware of the gin router group followed by Handle
+	Handlers gin.HandlersChain
+
+	// engine serve the handler chain of the route for the batch calls
+	engine *routeEngine
+}
+
+// RouteRegistry record the routes registered by the route groups, it is shared by the handler and the derived handlers
+type RouteRegistry struct {
+	mu     sync.RWMutex
+	routes []RouteInfo
+	names  map[string]int
+}
+
+// NewRouteRegistry return an empty route registry
+func NewRouteRegistry() *RouteRegistry {
+	return &RouteRegistry{names: map[string]int{}}
+}
+
+// Routes return the registered routes in registration order
+func (r *RouteRegistry) Routes() []RouteInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]RouteInfo(nil), r.routes...)
+}
+
+// Lookup return the route of the name
+func (r *RouteRegistry) Lookup(name string) (RouteInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, ok := r.names[name]
+	if !ok {
+		return RouteInfo{}, false
+	}
+	return r.routes[i], true
+}
+
+// add record the route, it panics if the name of the route is registered, as registering a duplicate gin route does
+func (r *RouteRegistry) add(info RouteInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.names[info.Name]; ok {
+		panic(fmt.Sprintf("route name %q of %s %s is already registered", info.Name, info.Method, info.Path))
+	}
+	if info.engine == nil {
+		info.engine = &routeEngine{}
+	}
+	r.names[info.Name] = len(r.routes)
+	r.routes = append(r.routes, info)
+}
+
+// describeRoute build the route info by the route options and the error specs of the handler, the middleware
+// precedes the handle in the handler chain of the route
+func (h *Handler) describeRoute(method, path string, req, resp reflect.Type, pagination string, middleware gin.HandlersChain, handle gin.HandlerFunc, opts []RouteOption) RouteInfo {
+	cfg := h.routeConfig(opts...)
+	if cfg.response != nil {
+		resp = cfg.response
+	}
+	if req == reflect.TypeOf(Empty{}) {
+		req = nil
+	}
+
+	info := RouteInfo{
+		Name:       cfg.name,
+		Method:     method,
+		Path:       path,
+		Summary:    cfg.summary,
+		Tags:       cfg.tags,
+		Request:    req,
+		Response:   resp,
+		Pagination: pagination,
+		Display:    req != nil && reflect.PtrTo(req).Implements(displayGetterType),
+		Errors:     h.routeErrors(req != nil, cfg),
+		Handle:     handle,
+		Handlers:   append(append(gin.HandlersChain(nil), middleware...), handle),
+	}
+	if info.Name == "" {
+		info.Name = routeName(method, path)
+	}
+	if resp != nil && isStreamType(resp) {
+		info.Stream, info.Response = true, streamElemType(resp)
+	}
+	if pagination != "" {
+		info.PaginationOptions = cfg.pagination
+	}
+	return info
+}
+
+// describeFunc return the request type, response type and pagination kind of a handler function for HandleMiddleware
+func describeFunc(fun handlerFun) (req, resp reflect.Type, pagination string) {
+	ft := reflect.TypeOf(fun)
+	switch ft.In(ft.NumIn() - 1) {
+	case paginationQueryType:
+		pagination = PaginationOffset
+	case cursorQueryType:
+		pagination = PaginationCursor
+	}
+
+	if ft.NumIn() > 1 && ft.In(1) != paginationQueryType && ft.In(1) != cursorQueryType {
+		req = ft.In(1).Elem()
+	}
+	if ft.NumOut() == 2 && pagination == "" {
+		resp = ft.Out(0)
+	}
+	return req, resp, pagination
+}
+
+// routeErrors return the specs of the registered errors and the errors responded by the handler itself,
+// the specs of the same code are merged to the first one, and the zero HTTPStatus is kept as it is
+func (h *Handler) routeErrors(hasRequest bool, cfg *routeConfig) []ErrorSpec {
+	specs := h.errorRegistry.Specs()
+	if hasRequest && h.validationErrCode != 0 {
+		specs = append(specs, ErrorSpec{HTTPStatus: http.StatusBadRequest, Code: h.validationErrCode, Message: "validation error"})
+	}
+	if cfg.timeout > 0 && h.timeoutErrCode != 0 {
+		specs = append(specs, ErrorSpec{HTTPStatus: http.StatusGatewayTimeout, Code: h.timeoutErrCode, Message: ErrHandlerTimeout.Error()})
+	}
+	if h.panicErrCode != 0 {
+		specs = append(specs, ErrorSpec{HTTPStatus: http.StatusInternalServerError, Code: h.panicErrCode, Message: "internal server error"})
+	}
+
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].Code != specs[j].Code {
+			return specs[i].Code < specs[j].Code
+		}
+		return specs[i].Message < specs[j].Message
+	})
+
+	var errs []ErrorSpec
+	for _, spec := range specs {
+		if spec.Code == 0 || (len(errs) > 0 && errs[len(errs)-1].Code == spec.Code) {
+			continue
+		}
+		errs = append(errs, spec)
+	}
+	return errs
+}
+
+// routeName return the camel case of the method and the path, the path params are prefixed with By
+func routeName(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			b.WriteString("By")
+			segment = segment[1:]
+		}
+
+		words := strings.FieldsFunc(segment, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
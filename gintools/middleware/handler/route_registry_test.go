@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type registryUser struct {
+	ID   uint64 `json:"id"`
+	Name string `json:"name"`
+}
+
+type registryListReq struct {
+	Display
+	Status string `json:"status" form:"status"`
+}
+
+func TestRouteRegistry(t *testing.T) {
+	errNotFound := errors.New("user not found")
+	h := NewHandler(map[error]int{errNotFound: 40400}, nil, nil).SetValidationErrCode(40000)
+	users := h.Group(gin.New(), "/api", WithRouteOptions(WithTags("users"))).Group("/users")
+
+	users.GET("/:id", func(c *gin.Context, req *struct {
+		ID uint64 `uri:"id"`
+	}) (*registryUser, error) {
+		return nil, errNotFound
+	}, WithSummary("get a user"))
+	HandlePaginated(users, http.MethodPost, "/search", func(c *gin.Context, req *registryListReq, query *PaginationQuery) ([]registryUser, uint64, error) {
+		return nil, 0, nil
+	}, WithName("searchUsers"))
+	users.DELETE("/", func(c *gin.Context) error { return nil })
+
+	routes := h.Routes().Routes()
+	if len(routes) != 3 {
+		t.Fatalf("len(Routes()) = %d, want 3", len(routes))
+	}
+
+	get := routes[0]
+	if get.Name != "getApiUsersById" || get.Path != "/api/users/:id" || get.Summary != "get a user" || !reflect.DeepEqual(get.Tags, []string{"users"}) {
+		t.Errorf("route = %s %s %q %v", get.Name, get.Path, get.Summary, get.Tags)
+	}
+	if get.Response != reflect.TypeOf(&registryUser{}) || get.Request.Kind() != reflect.Struct || get.Display {
+		t.Errorf("route types = %v %v %v", get.Request, get.Response, get.Display)
+	}
+	wantErrors := []ErrorSpec{{HTTPStatus: http.StatusBadRequest, Code: 40000, Message: "validation error"}, {Code: 40400, Message: "user not found"}}
+	if !reflect.DeepEqual(get.Errors, wantErrors) {
+		t.Errorf("route errors = %v, want %v", get.Errors, wantErrors)
+	}
+
+	search, ok := h.Routes().Lookup("searchUsers")
+	if !ok || search.Pagination != PaginationOffset || !search.Display || search.Response != reflect.TypeOf([]registryUser{}) {
+		t.Errorf("Lookup(searchUsers) = %+v, %v", search, ok)
+	}
+	if del := routes[2]; del.Name != "deleteApiUsers" || del.Path != "/api/users/" || del.Request != nil || del.Response != nil || len(del.Errors) != 1 {
+		t.Errorf("route = %+v", del)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate route name does not panic")
+		}
+	}()
+	users.GET("/other", func(c *gin.Context) error { return nil }, WithName("searchUsers"))
+}
@@ -0,0 +1,478 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamFormat is the wire format of the streaming responses
+type StreamFormat int
+
+const (
+	// StreamAuto choose NDJSON if the request accepts application/x-ndjson, otherwise SSE
+	StreamAuto StreamFormat = iota
+	// StreamSSE write the items as Server-Sent Events
+	StreamSSE
+	// StreamNDJSON write the items as newline-delimited JSON
+	StreamNDJSON
+)
+
+// the content types of the streaming responses
+const (
+	MIMEEventStream = "text/event-stream"
+	MIMENDJSON      = "application/x-ndjson"
+)
+
+// StreamOptions is the options of the streaming responses
+type StreamOptions struct {
+	Format StreamFormat
+	// Heartbeat is the interval of the heartbeats written when there are no items, zero means no heartbeat.
+	// The heartbeat is a comment line of SSE, and an empty line of NDJSON which the clients should skip.
+	Heartbeat time.Duration
+}
+
+// DefaultStreamOptions is the stream options used if the handler does not set
+var DefaultStreamOptions = StreamOptions{Format: StreamAuto, Heartbeat: 15 * time.Second}
+
+// Event is a streaming item with the id and the name of the Server-Sent Event, the Data is encoded as the
+// data of the event. The id and name are ignored by NDJSON, which writes the Data only.
+type Event struct {
+	ID   string
+	Name string
+	Data interface{}
+}
+
+// SetStreamOptions set the default options of the streaming routes
+func (h *Handler) SetStreamOptions(opts StreamOptions) *Handler {
+	h.streamOptions = opts
+	return h
+}
+
+// WithStream override the stream options of the route
+func WithStream(opts StreamOptions) RouteOption {
+	return func(cfg *routeConfig) {
+		cfg.stream = opts
+	}
+}
+
+// streamSource produce the items by emit until it returns false, an item with a non-nil error terminates the stream
+type streamSource func(ctx context.Context, emit func(item interface{}, err error) bool)
+
+type streamItem struct {
+	item  interface{}
+	err   error
+	panic *PanicError
+}
+
+// Stream wrap a typed handler function which return a channel, the items received from the channel are streamed
+// until it is closed. An item implementing error terminates the stream with the error responded through the
+// response adaptor, such as the items of a chan interface{}. The producer should stop sending once
+// c.Request.Context() is done, which happens when the client disconnects or the stream is terminated.
+// The route timeout does not apply to the streaming routes.
+func Stream[Req any, T any](h *Handler, fun func(*gin.Context, *Req) (<-chan T, error), opts ...RouteOption) func(*gin.Context) {
+	return typedStream(h, func(c *gin.Context, req *Req) (streamSource, error) {
+		ch, err := fun(c, req)
+		if err != nil {
+			return nil, err
+		}
+		return chanSource(reflect.ValueOf(ch)), nil
+	}, opts)
+}
+
+// StreamSeq wrap a typed handler function which return an iterator, the items yielded are streamed until
+// the iterator returns. The yield returns false once the client disconnects.
+func StreamSeq[Req any, T any](h *Handler, fun func(*gin.Context, *Req) (func(yield func(T) bool), error), opts ...RouteOption) func(*gin.Context) {
+	return typedStream(h, func(c *gin.Context, req *Req) (streamSource, error) {
+		seq, err := fun(c, req)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, emit func(interface{}, error) bool) {
+			seq(func(item T) bool {
+				return emit(item, nil)
+			})
+		}, nil
+	}, opts)
+}
+
+// StreamSeq2 wrap a typed handler function which return an iterator of the items and errors,
+// a non-nil error terminates the stream with the error responded through the response adaptor
+func StreamSeq2[Req any, T any](h *Handler, fun func(*gin.Context, *Req) (func(yield func(T, error) bool), error), opts ...RouteOption) func(*gin.Context) {
+	return typedStream(h, func(c *gin.Context, req *Req) (streamSource, error) {
+		seq, err := fun(c, req)
+		if err != nil {
+			return nil, err
+		}
+		return func(ctx context.Context, emit func(interface{}, error) bool) {
+			seq(func(item T, err error) bool {
+				return emit(item, err)
+			})
+		}, nil
+	}, opts)
+}
+
+func typedStream[Req any](h *Handler, open func(*gin.Context, *Req) (streamSource, error), opts []RouteOption) func(*gin.Context) {
+	cfg := h.routeConfig(opts...)
+	return func(context *gin.Context) {
+		defer h.recoverPanic(context)
+		if ok := h.applyFrontFilters(context); !ok {
+			return
+		}
+
+		req, err := bindTypedReqArg[Req](h, context)
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+
+		cancel := withStreamContext(context)
+		defer cancel()
+
+		source, err := open(context, req)
+		if err != nil {
+			h.respondError(context, err)
+			return
+		}
+		h.stream(context, source, cfg.stream)
+	}
+}
+
+// withStreamContext replace the request context by a cancelable one, which is cancelled when the stream ends,
+// so that the producers started by the handler function stop
+func withStreamContext(c *gin.Context) context.CancelFunc {
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	return cancel
+}
+
+// isStreamType report whether the return type of the handler function for HandleMiddleware is streamed,
+// which is a receivable channel, func(yield func(T) bool) or func(yield func(T, error) bool)
+func isStreamType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Chan:
+		return t.ChanDir()&reflect.RecvDir != 0
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 {
+			return false
+		}
+		yield := t.In(0)
+		if yield.Kind() != reflect.Func || yield.NumOut() != 1 || yield.Out(0).Kind() != reflect.Bool {
+			return false
+		}
+		return yield.NumIn() == 1 || (yield.NumIn() == 2 && yield.In(1) == errorType)
+	}
+	return false
+}
+
+// streamElemType return the type of the items of the stream type
+func streamElemType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Chan {
+		return t.Elem()
+	}
+	return t.In(0).In(0)
+}
+
+// reflectSource return the stream source of the value returned by the handler function for HandleMiddleware
+func reflectSource(v reflect.Value) streamSource {
+	if v.Kind() == reflect.Chan {
+		return chanSource(v)
+	}
+
+	yieldType := v.Type().In(0)
+	return func(ctx context.Context, emit func(interface{}, error) bool) {
+		yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+			var err error
+			if len(args) == 2 && !args[1].IsNil() {
+				err = args[1].Interface().(error)
+			}
+			return []reflect.Value{reflect.ValueOf(emit(args[0].Interface(), err))}
+		})
+		v.Call([]reflect.Value{yield})
+	}
+}
+
+// chanSource receive the items from the channel until it is closed or the context is done
+func chanSource(ch reflect.Value) streamSource {
+	return func(ctx context.Context, emit func(interface{}, error) bool) {
+		if ch.IsNil() {
+			return
+		}
+
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: ch},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		}
+		for {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 1 || !ok {
+				return
+			}
+
+			value := item.Interface()
+			if err, isErr := value.(error); isErr {
+				emit(nil, err)
+				return
+			}
+			if !emit(value, nil) {
+				return
+			}
+		}
+	}
+}
+
+// stream write the items of the source until it ends, the client disconnects or an error terminates it
+func (h *Handler) stream(c *gin.Context, source streamSource, opts StreamOptions) {
+	ctx := c.Request.Context()
+	items := make(chan streamItem)
+	go func() {
+		defer close(items)
+		defer func() {
+			if r := recover(); r != nil {
+				select {
+				case items <- streamItem{panic: newPanicError(r)}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+
+		source(ctx, func(item interface{}, err error) bool {
+			select {
+			case items <- streamItem{item: item, err: err}:
+				return err == nil
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+
+	w := newStreamWriter(c, opts.Format)
+	var ticker *time.Ticker
+	var heartbeat <-chan time.Time
+	if opts.Heartbeat > 0 {
+		ticker = time.NewTicker(opts.Heartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case item, ok := <-items:
+			switch {
+			case !ok:
+				w.end()
+				return
+			case item.panic != nil:
+				// the panic is recovered by recoverPanic, which only aborts as the response is written
+				h.streamError(c, w, item.panic)
+				panic(item.panic)
+			case item.err != nil:
+				h.streamError(c, w, item.err)
+				return
+			}
+
+			if err := w.write(item.item); err != nil {
+				h.streamError(c, w, errors.Wrap(err, "encode stream item"))
+				return
+			}
+			// the heartbeat is only sent when there are no items
+			if ticker != nil {
+				ticker.Reset(opts.Heartbeat)
+			}
+
+		case <-heartbeat:
+			w.heartbeat()
+
+		case <-ctx.Done():
+			// the client disconnected or the stream is cancelled, nothing can be written any more
+			c.Abort()
+			return
+		}
+
+		if w.err != nil {
+			c.Abort()
+			return
+		}
+	}
+}
+
+// streamError write the error terminating the stream, the error is formatted by the response adaptor
+func (h *Handler) streamError(c *gin.Context, w *streamWriter, err error) {
+	capture := captureResponse(c, func(cp *gin.Context) {
+		if panicErr, ok := err.(*PanicError); ok {
+			h.respondErrorSpec(cp, err, h.panicSpec(panicErr))
+		} else {
+			h.respondError(cp, err)
+		}
+	})
+	c.Abort()
+
+	w.fail(bytes.TrimSpace(capture.body.Bytes()))
+}
+
+// streamWriter write the items in the format of the stream, the response is committed with the first write
+type streamWriter struct {
+	c      *gin.Context
+	format StreamFormat
+	err    error
+}
+
+func newStreamWriter(c *gin.Context, format StreamFormat) *streamWriter {
+	if format == StreamAuto {
+		format = StreamSSE
+		if strings.Contains(c.GetHeader("Accept"), MIMENDJSON) {
+			format = StreamNDJSON
+		}
+	}
+
+	header := c.Writer.Header()
+	if format == StreamNDJSON {
+		header.Set("Content-Type", MIMENDJSON)
+	} else {
+		header.Set("Content-Type", MIMEEventStream)
+		header.Set("Connection", "keep-alive")
+	}
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := &streamWriter{c: c, format: format}
+	w.flush("")
+	return w
+}
+
+func (w *streamWriter) write(item interface{}) error {
+	var id, name string
+	switch event := item.(type) {
+	case Event:
+		id, name, item = event.ID, event.Name, event.Data
+	case *Event:
+		id, name, item = event.ID, event.Name, event.Data
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	if w.format == StreamNDJSON {
+		w.flush(string(data) + "\n")
+		return nil
+	}
+
+	var b strings.Builder
+	if id != "" {
+		fmt.Fprintf(&b, "id: %s\n", sseField(id))
+	}
+	if name != "" {
+		fmt.Fprintf(&b, "event: %s\n", sseField(name))
+	}
+	fmt.Fprintf(&b, "data: %s\n\n", data)
+	w.flush(b.String())
+	return nil
+}
+
+func (w *streamWriter) heartbeat() {
+	if w.format == StreamNDJSON {
+		w.flush("\n")
+		return
+	}
+	w.flush(": heartbeat\n\n")
+}
+
+// end write the end event of SSE, so that the clients close the event source instead of reconnecting
+func (w *streamWriter) end() {
+	if w.format == StreamSSE {
+		w.flush("event: end\ndata: {}\n\n")
+	}
+}
+
+// fail write the error response as the error event of SSE, or the last line of NDJSON
+func (w *streamWriter) fail(body []byte) {
+	if w.format == StreamNDJSON {
+		w.flush(string(body) + "\n")
+		return
+	}
+	w.flush("event: error\ndata: " + strings.ReplaceAll(string(body), "\n", "\ndata: ") + "\n\n")
+}
+
+func (w *streamWriter) flush(s string) {
+	if w.err != nil {
+		return
+	}
+
+	if s != "" {
+		if _, err := w.c.Writer.WriteString(s); err != nil {
+			w.err = err
+			return
+		}
+	} else {
+		w.c.Writer.WriteHeaderNow()
+	}
+	w.c.Writer.Flush()
+}
+
+// sseField strip the line breaks which end the field of SSE
+func sseField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
+
+// captureResponse run the processing function with a copy of the gin context, and capture the response it writes
+func captureResponse(c *gin.Context, handle func(cp *gin.Context)) *captureWriter {
+	capture := &captureWriter{ResponseWriter: c.Writer, header: http.Header{}, status: http.StatusOK}
+	cp := c.Copy()
+	cp.Writer = capture
+	handle(cp)
+	return capture
+}
+
+// captureWriter capture the response written by the response adaptor, instead of writing it to the client
+type captureWriter struct {
+	gin.ResponseWriter
+	header  http.Header
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+func (w *captureWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *captureWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+func (w *captureWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+func (w *captureWriter) Written() bool {
+	return w.written
+}
+
+func (w *captureWriter) Status() int {
+	return w.status
+}
+
+func (w *captureWriter) Size() int {
+	return w.body.Len()
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveStream(engine *gin.Engine, target, accept string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	return w
+}
+
+func TestStream(t *testing.T) {
+	errBoom := errors.New("boom")
+	h := NewHandler(map[error]int{errBoom: 50001}, nil, nil).SetPanicErrCode(50000)
+	engine := gin.New()
+	engine.GET("/chan", h.HandleMiddleware(func(c *gin.Context) (<-chan int, error) {
+		ch := make(chan int, 3)
+		ch <- 1
+		ch <- 2
+		ch <- 3
+		close(ch)
+		return ch, nil
+	}))
+	engine.GET("/events", StreamSeq(h, func(c *gin.Context, req *Empty) (func(yield func(Event) bool), error) {
+		return func(yield func(Event) bool) {
+			_ = yield(Event{ID: "1", Name: "block", Data: map[string]int{"height": 7}}) &&
+				yield(Event{ID: "2", Name: "block", Data: map[string]int{"height": 8}})
+		}, nil
+	}))
+	engine.GET("/fail", StreamSeq2(h, func(c *gin.Context, req *Empty) (func(yield func(int, error) bool), error) {
+		return func(yield func(int, error) bool) {
+			_ = yield(1, nil) && yield(0, errBoom) && yield(2, nil)
+		}, nil
+	}))
+	engine.GET("/panic", h.HandleMiddleware(func(c *gin.Context) (func(yield func(int) bool), error) {
+		return func(yield func(int) bool) {
+			yield(1)
+			panic("stream boom")
+		}, nil
+	}))
+	engine.GET("/early", Stream(h, func(c *gin.Context, req *Empty) (<-chan int, error) {
+		return nil, errBoom
+	}))
+
+	w := serveStream(engine, "/chan", "")
+	if ct := w.Header().Get("Content-Type"); ct != MIMEEventStream {
+		t.Errorf("Content-Type = %q, want %q", ct, MIMEEventStream)
+	}
+	if want := "data: 1\n\ndata: 2\n\ndata: 3\n\nevent: end\ndata: {}\n\n"; w.Body.String() != want {
+		t.Errorf("SSE body = %q, want %q", w.Body.String(), want)
+	}
+
+	w = serveStream(engine, "/events", "")
+	if want := "id: 1\nevent: block\ndata: {\"height\":7}\n\nid: 2\nevent: block\ndata: {\"height\":8}\n\nevent: end\ndata: {}\n\n"; w.Body.String() != want {
+		t.Errorf("SSE events = %q, want %q", w.Body.String(), want)
+	}
+
+	w = serveStream(engine, "/events", MIMENDJSON)
+	if ct := w.Header().Get("Content-Type"); ct != MIMENDJSON || w.Body.String() != "{\"height\":7}\n{\"height\":8}\n" {
+		t.Errorf("NDJSON = %q %q", ct, w.Body.String())
+	}
+
+	w = serveStream(engine, "/fail", MIMENDJSON)
+	if want := "1\n{\"code\":50001,\"msg\":\"boom\"}\n"; w.Body.String() != want {
+		t.Errorf("NDJSON terminated by error = %q, want %q", w.Body.String(), want)
+	}
+
+	w = serveStream(engine, "/panic", "")
+	if want := "data: 1\n\nevent: error\ndata: {\"code\":50000,\"msg\":\"internal server error\"}\n\n"; w.Body.String() != want {
+		t.Errorf("SSE terminated by panic = %q, want %q", w.Body.String(), want)
+	}
+
+	w = serveStream(engine, "/early", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") == MIMEEventStream || !strings.Contains(w.Body.String(), `"code":50001`) {
+		t.Errorf("error before streaming = %d %q %s, want the plain error response", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestStreamHeartbeat(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetStreamOptions(StreamOptions{Format: StreamNDJSON, Heartbeat: 5 * time.Millisecond})
+	engine := gin.New()
+	engine.GET("/slow", Stream(h, func(c *gin.Context, req *Empty) (<-chan string, error) {
+		ch := make(chan string)
+		go func() {
+			defer close(ch)
+			time.Sleep(30 * time.Millisecond)
+			ch <- "done"
+		}()
+		return ch, nil
+	}, WithStream(StreamOptions{Format: StreamSSE, Heartbeat: 5 * time.Millisecond})))
+
+	body := serveStream(engine, "/slow", MIMENDJSON).Body.String()
+	if !strings.HasPrefix(body, ": heartbeat\n\n") || !strings.HasSuffix(body, "data: \"done\"\n\nevent: end\ndata: {}\n\n") {
+		t.Errorf("body = %q, want the SSE heartbeats of the route options before the item", body)
+	}
+
+	engine.GET("/busy", Stream(h, func(c *gin.Context, req *Empty) (<-chan int, error) {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for i := 0; i < 20; i++ {
+				time.Sleep(2 * time.Millisecond)
+				ch <- i
+			}
+		}()
+		return ch, nil
+	}, WithStream(StreamOptions{Format: StreamSSE, Heartbeat: 20 * time.Millisecond})))
+
+	if body := serveStream(engine, "/busy", MIMENDJSON).Body.String(); strings.Contains(body, "heartbeat") {
+		t.Errorf("body = %q, want no heartbeat while the items are written", body)
+	}
+}
+
+func TestStreamDisconnect(t *testing.T) {
+	stopped := make(chan struct{})
+	h := NewHandler(nil, nil, nil)
+	engine := gin.New()
+	engine.GET("/feed", Stream(h, func(c *gin.Context, req *Empty) (<-chan int, error) {
+		ctx := c.Request.Context()
+		ch := make(chan int)
+		go func() {
+			defer close(stopped)
+			for i := 0; ; i++ {
+				select {
+				case ch <- i:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch, nil
+	}))
+
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/feed", nil)
+	req.Header.Set("Accept", MIMENDJSON)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "0\n" {
+		t.Fatalf("first line = %q, %v", line, err)
+	}
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the producer is not stopped after the client disconnected")
+	}
+}
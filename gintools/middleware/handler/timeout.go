@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrHandlerTimeout is returned when the handler function does not return before the deadline of the route
+var ErrHandlerTimeout = errors.New("handler timeout")
+
+var stdContextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// SetTimeout set the default deadline of the handler functions, zero means no deadline
+func (h *Handler) SetTimeout(timeout time.Duration) *Handler {
+	h.timeout = timeout
+	return h
+}
+
+// SetTimeoutErrCode set the error code responded with HTTP 504 when the handler function timed out
+func (h *Handler) SetTimeoutErrCode(code int) *Handler {
+	h.timeoutErrCode = code
+	return h
+}
+
+// handleOutcome is the outcome of the handler function run in another goroutine
+type handleOutcome[R any] struct {
+	result R
+	err    error
+	panic  *PanicError
+}
+
+// runHandleFunc run the handler function bounded by the timeout. If the timeout is set, the request context is
+// replaced by the one with the deadline, which is cancelled when the deadline passes or the call returns, and
+// the function is run with a copy of the gin context in another goroutine, ErrHandlerTimeout is returned once the
+// deadline passes. The response headers, the written response and the context keys of the copy are merged back
+// if the function returns in time. The function should pass c.Request.Context() downstream to be cancelled,
+// because the Done of gin context is always nil.
+func runHandleFunc[R any](c *gin.Context, timeout time.Duration, fun func(c *gin.Context) (R, error)) (R, error) {
+	if timeout <= 0 {
+		return fun(c)
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	// the writer of the copy is nil, so the response is captured and merged back once the function returns
+	capture := &captureWriter{ResponseWriter: c.Writer, header: c.Writer.Header().Clone(), status: http.StatusOK}
+	cp := c.Copy()
+	cp.Writer = capture
+	cp.Keys = make(map[string]interface{}, len(c.Keys))
+	for key, val := range c.Keys {
+		cp.Keys[key] = val
+	}
+
+	done := make(chan handleOutcome[R], 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					done <- handleOutcome[R]{err: errors.Wrap(context.Canceled, "handler aborted")}
+					return
+				}
+				done <- handleOutcome[R]{panic: newPanicError(r)}
+			}
+		}()
+
+		result, err := fun(cp)
+		done <- handleOutcome[R]{result: result, err: err}
+	}()
+
+	var zero R
+	select {
+	case outcome := <-done:
+		if outcome.panic != nil {
+			// the panic is recovered by recoverPanic of the request goroutine with the stack of the handler
+			panic(outcome.panic)
+		}
+		mergeCopy(c, cp, capture)
+		return outcome.result, outcome.err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return zero, errors.Wrap(ErrHandlerTimeout, timeout.String())
+		}
+		return zero, errors.Wrap(ctx.Err(), "request canceled")
+	}
+}
+
+// mergeCopy merge the response headers, the written response and the context keys of the copy back into c
+func mergeCopy(c *gin.Context, cp *gin.Context, capture *captureWriter) {
+	header := c.Writer.Header()
+	for key := range header {
+		if _, ok := capture.header[key]; !ok {
+			header.Del(key)
+		}
+	}
+	for key, values := range capture.header {
+		header[key] = values
+	}
+
+	for key, val := range cp.Keys {
+		c.Set(key, val)
+	}
+
+	if capture.written {
+		c.Status(capture.status)
+		c.Writer.Write(capture.body.Bytes())
+	}
+}
+
+// handleFuncContext return the first argument of the handler function, which is either the gin context or
+// the request context
+func handleFuncContext(fun handlerFun, c *gin.Context) interface{} {
+	if reflect.TypeOf(fun).In(0) == stdContextType {
+		return c.Request.Context()
+	}
+	return c
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetResponseAdaptor(&StatusResponse{}).SetTimeout(20 * time.Millisecond).SetTimeoutErrCode(50400)
+
+	cancelled := make(chan struct{})
+	engine := gin.New()
+	engine.GET("/slow", h.HandleMiddleware(func(ctx context.Context) (string, error) {
+		select {
+		case <-ctx.Done():
+			close(cancelled)
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+			return "late", nil
+		}
+	}))
+	engine.GET("/fast", h.HandleMiddleware(func(ctx context.Context) (string, error) {
+		return "fast", nil
+	}))
+	engine.GET("/override", h.HandleMiddleware(func(c *gin.Context) (string, error) {
+		time.Sleep(40 * time.Millisecond)
+		return "override", c.Request.Context().Err()
+	}, WithTimeout(time.Second)))
+	engine.GET("/typed", JSON(h, func(c *gin.Context, req *Empty) (string, error) {
+		<-c.Request.Context().Done()
+		return "", nil
+	}, WithTimeout(10*time.Millisecond)))
+	engine.GET("/panic", JSON(h, func(c *gin.Context, req *Empty) (string, error) {
+		panic("boom")
+	}))
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	if w := get("/slow"); w.Code != http.StatusGatewayTimeout || !strings.Contains(w.Body.String(), `"code":50400`) {
+		t.Errorf("slow = %d %s, want 504 with the timeout code", w.Code, w.Body.String())
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("the context of the timed out handler is not cancelled")
+	}
+
+	if w := get("/fast"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "fast") {
+		t.Errorf("fast = %d %s, want 200", w.Code, w.Body.String())
+	}
+	if w := get("/override"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "override") {
+		t.Errorf("override = %d %s, want the route deadline override the handler", w.Code, w.Body.String())
+	}
+	if w := get("/typed"); w.Code != http.StatusGatewayTimeout {
+		t.Errorf("typed = %d, want 504", w.Code)
+	}
+	if w := get("/panic"); w.Code != http.StatusInternalServerError {
+		t.Errorf("panic = %d, want the panic in the handler goroutine recovered", w.Code)
+	}
+}
+
+func TestTimeoutMergeCopy(t *testing.T) {
+	h := NewHandler(nil, nil, nil).SetTimeout(time.Second)
+
+	var key interface{}
+	engine := gin.New()
+	engine.GET("/header", func(c *gin.Context) {
+		h.HandleMiddleware(func(c *gin.Context) (string, error) {
+			c.Header("X-A", "1")
+			c.Set("k", "v")
+			return "ok", nil
+		})(c)
+		key, _ = c.Get("k")
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/header", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"data":"ok"`) || w.Header().Get("X-A") != "1" {
+		t.Errorf("header = %d %s %v, want the header set under the timeout responded", w.Code, w.Body.String(), w.Header())
+	}
+	if key != "v" {
+		t.Errorf("key = %v, want the key set under the timeout merged back", key)
+	}
+}
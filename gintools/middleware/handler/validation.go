@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/go-playground/validator.v8"
+)
+
+// FieldError describe a field of the request argument which failed binding or validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Path    string `json:"path"`
+	Source  string `json:"source,omitempty"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ValidationError represent the request argument is failed to bind or validate
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error satisfies the error interface
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		msgs[i] = field.Message
+	}
+	return "invalid request: " + strings.Join(msgs, "; ")
+}
+
+// newValidationError convert the binding or validation error of reqArg to ValidationError,
+// other errors are returned as-is
+func newValidationError(reqArg interface{}, err error) error {
+	switch e := err.(type) {
+	case validator.ValidationErrors:
+		return &ValidationError{Fields: convertValidatorErrors(reflect.TypeOf(reqArg), e)}
+
+	case *BindError:
+		field := FieldError{
+			Field:   e.Field,
+			Path:    e.Key,
+			Source:  e.Source,
+			Rule:    "type",
+			Message: e.Error(),
+		}
+		if e.Field == "" {
+			field.Rule = "format"
+		}
+		return &ValidationError{Fields: []FieldError{field}}
+	}
+	return err
+}
+
+func convertValidatorErrors(reqType reflect.Type, errs validator.ValidationErrors) []FieldError {
+	fields := make([]FieldError, 0, len(errs))
+	for _, e := range errs {
+		path := jsonPath(reqType, e.FieldNamespace)
+		fields = append(fields, FieldError{
+			Field:   e.Field,
+			Path:    path,
+			Rule:    e.Tag,
+			Param:   e.Param,
+			Message: validationMessage(path, e.Tag, e.Param),
+		})
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Path < fields[j].Path })
+	return fields
+}
+
+// jsonPath translate the validator namespace, such as "Req.Items[0].Name", into the json path "items[0].name"
+func jsonPath(typ reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+	var path []string
+	for _, segment := range segments[1:] {
+		name, index := segment, ""
+		if i := strings.Index(segment, "["); i >= 0 {
+			name, index = segment[:i], segment[i:]
+		}
+
+		typ = indirectType(typ)
+		if typ.Kind() != reflect.Struct {
+			path = append(path, segment)
+			continue
+		}
+
+		field, ok := typ.FieldByName(name)
+		if !ok {
+			path = append(path, segment)
+			continue
+		}
+
+		typ = field.Type
+		if index != "" && (typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array || typ.Kind() == reflect.Map) {
+			typ = typ.Elem()
+		}
+
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			continue
+		}
+		path = append(path, jsonFieldName(field)+index)
+	}
+	return strings.Join(path, ".")
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func validationMessage(path, rule, param string) string {
+	switch rule {
+	case "required":
+		return fmt.Sprintf("%s is required", path)
+	case "min":
+		return fmt.Sprintf("%s must be at least %s", path, param)
+	case "max":
+		return fmt.Sprintf("%s must be at most %s", path, param)
+	case "len":
+		return fmt.Sprintf("%s must have the length %s", path, param)
+	case "eq":
+		return fmt.Sprintf("%s must be equal to %s", path, param)
+	case "ne":
+		return fmt.Sprintf("%s must not be equal to %s", path, param)
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", path, param)
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s", path, param)
+	case "lt":
+		return fmt.Sprintf("%s must be less than %s", path, param)
+	case "lte":
+		return fmt.Sprintf("%s must be less than or equal to %s", path, param)
+	case "email", "url", "uri", "uuid", "hexadecimal", "numeric", "alpha", "alphanum":
+		return fmt.Sprintf("%s must be a valid %s", path, rule)
+	}
+
+	if param != "" {
+		return fmt.Sprintf("%s failed on the %s=%s rule", path, rule, param)
+	}
+	return fmt.Sprintf("%s failed on the %s rule", path, rule)
+}
@@ -0,0 +1,193 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+)
+
+// maxSwapRetries is the max times of retrying the compare-and-swap when the state is changed concurrently
+const maxSwapRetries = 16
+
+// ErrStoreContention is returned when the state of the key is changed concurrently for too many times
+var ErrStoreContention = errors.New("rate limit store contention")
+
+// Result is the result of taking a request from the limiter
+type Result struct {
+	Allowed bool
+	// Limit is the max number of requests in the period
+	Limit int
+	// Remaining is the number of requests allowed right now
+	Remaining int
+	// Reset is the duration until the limit is fully restored
+	Reset time.Duration
+	// RetryAfter is the duration until the next request is allowed if it is not allowed
+	RetryAfter time.Duration
+}
+
+// Limiter decide whether a request of the key is allowed
+type Limiter interface {
+	Allow(key string) (Result, error)
+}
+
+// swap run the update on the state of the key until the compare-and-swap succeeded, the update return the new
+// state to be stored with its ttl, or a nil state if nothing need to be stored
+func swap(store Store, key string, update func(old []byte, ok bool) ([]byte, time.Duration, Result, error)) (Result, error) {
+	for i := 0; i < maxSwapRetries; i++ {
+		old, ok, err := store.Get(key)
+		if err != nil {
+			return Result{}, errors.Wrap(err, "get rate limit state")
+		}
+
+		state, ttl, result, err := update(old, ok)
+		if err != nil || state == nil {
+			return result, err
+		}
+
+		if !ok {
+			old = nil
+		}
+
+		swapped, err := store.CompareAndSwap(key, old, state, ttl)
+		if err != nil {
+			return Result{}, errors.Wrap(err, "swap rate limit state")
+		}
+		if swapped {
+			return result, nil
+		}
+	}
+	return Result{}, ErrStoreContention
+}
+
+// TokenBucket allow bursts up to the capacity, and refill the tokens at the rate of capacity per period
+type TokenBucket struct {
+	store    Store
+	capacity int
+	period   time.Duration
+	now      func() time.Time
+}
+
+// NewTokenBucket return a token bucket limiter, whose bucket is refilled from empty to full in the period
+func NewTokenBucket(store Store, capacity int, period time.Duration) *TokenBucket {
+	return &TokenBucket{store: store, capacity: capacity, period: period, now: time.Now}
+}
+
+// Allow take a token of the key
+func (b *TokenBucket) Allow(key string) (Result, error) {
+	return swap(b.store, key, func(old []byte, ok bool) ([]byte, time.Duration, Result, error) {
+		now := b.now()
+		capacity := float64(b.capacity)
+		interval := float64(b.period) / capacity
+
+		tokens := capacity
+		if ok {
+			var last int64
+			if _, err := fmt.Sscanf(string(old), "%g:%d", &tokens, &last); err != nil {
+				return nil, 0, Result{}, errors.Wrap(err, "parse token bucket state")
+			}
+			tokens = math.Min(capacity, tokens+float64(now.UnixNano()-last)/interval)
+		}
+
+		result := Result{Allowed: tokens >= 1, Limit: b.capacity}
+		if result.Allowed {
+			tokens--
+		} else {
+			result.RetryAfter = time.Duration((1 - tokens) * interval)
+		}
+		result.Remaining = int(tokens)
+		result.Reset = time.Duration((capacity - tokens) * interval)
+		if !result.Allowed {
+			return nil, 0, result, nil
+		}
+
+		state := []byte(fmt.Sprintf("%g:%d", tokens, now.UnixNano()))
+		return state, result.Reset + time.Millisecond, result, nil
+	})
+}
+
+// SlidingWindow allow limit requests in any window, the count of the sliding window is estimated by the counts
+// of the current and the previous fixed windows
+type SlidingWindow struct {
+	store  Store
+	limit  int
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewSlidingWindow return a sliding window limiter
+func NewSlidingWindow(store Store, limit int, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{store: store, limit: limit, window: window, now: time.Now}
+}
+
+// Allow count a request of the key
+func (w *SlidingWindow) Allow(key string) (Result, error) {
+	return swap(w.store, key, func(old []byte, ok bool) ([]byte, time.Duration, Result, error) {
+		now := w.now()
+		start := now.Truncate(w.window)
+
+		var prev, curr float64
+		if ok {
+			var stateStart int64
+			if _, err := fmt.Sscanf(string(old), "%d:%g:%g", &stateStart, &prev, &curr); err != nil {
+				return nil, 0, Result{}, errors.Wrap(err, "parse sliding window state")
+			}
+
+			switch start.UnixNano() - stateStart {
+			case 0:
+			case int64(w.window):
+				prev, curr = curr, 0
+			default:
+				prev, curr = 0, 0
+			}
+		}
+
+		limit := float64(w.limit)
+		elapsed := float64(now.Sub(start)) / float64(w.window)
+		estimate := prev*(1-elapsed) + curr
+
+		result := Result{Allowed: estimate+1 <= limit, Limit: w.limit}
+		if !result.Allowed {
+			result.RetryAfter = w.retryAfter(now, start, prev, curr)
+			result.Reset = w.reset(now, start, prev, curr)
+			return nil, 0, result, nil
+		}
+
+		curr++
+		result.Remaining = int(math.Max(0, limit-estimate-1))
+		result.Reset = w.reset(now, start, prev, curr)
+		state := []byte(fmt.Sprintf("%d:%g:%g", start.UnixNano(), prev, curr))
+		return state, 2 * w.window, result, nil
+	})
+}
+
+// reset return the duration until the counts of both windows slide out
+func (w *SlidingWindow) reset(now, start time.Time, prev, curr float64) time.Duration {
+	switch {
+	case curr > 0:
+		return start.Add(2 * w.window).Sub(now)
+	case prev > 0:
+		return start.Add(w.window).Sub(now)
+	}
+	return 0
+}
+
+// retryAfter return the duration until the estimated count drops to allow a request
+func (w *SlidingWindow) retryAfter(now, start time.Time, prev, curr float64) time.Duration {
+	limit := float64(w.limit)
+	window := float64(w.window)
+
+	// the request is allowed in the current window once enough of the previous window has slid out
+	if curr+1 <= limit && prev > 0 {
+		fraction := 1 - (limit-1-curr)/prev
+		return start.Add(time.Duration(fraction * window)).Sub(now)
+	}
+
+	// otherwise the current window becomes the previous one, and it need to slide out enough
+	fraction := 0.0
+	if curr > 0 {
+		fraction = math.Max(0, 1-(limit-1)/curr)
+	}
+	return start.Add(w.window + time.Duration(fraction*window)).Sub(now)
+}
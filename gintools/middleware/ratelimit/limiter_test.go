@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestTokenBucket(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	bucket := NewTokenBucket(&RedisStore{Client: newFakeRedis()}, 3, 3*time.Second)
+	bucket.now = clock.Now
+
+	for i := 0; i < 3; i++ {
+		result, err := bucket.Allow("k")
+		if err != nil || !result.Allowed || result.Remaining != 2-i {
+			t.Fatalf("request %d: Allow() = %+v, %v, want allowed with %d remaining", i, result, err, 2-i)
+		}
+	}
+
+	result, _ := bucket.Allow("k")
+	if result.Allowed || result.RetryAfter != time.Second || result.Reset != 3*time.Second {
+		t.Errorf("Allow() = %+v, want limited and retry after 1s", result)
+	}
+
+	clock.now = clock.now.Add(1500 * time.Millisecond)
+	if result, _ := bucket.Allow("k"); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Allow() = %+v, want allowed after a token refilled", result)
+	}
+	if result, _ := bucket.Allow("other"); !result.Allowed || result.Remaining != 2 {
+		t.Errorf("Allow(other) = %+v, want the keys limited separately", result)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	store := NewMemoryStore()
+	store.now = clock.Now
+	window := NewSlidingWindow(store, 4, 10*time.Second)
+	window.now = clock.Now
+
+	for i := 0; i < 4; i++ {
+		if result, _ := window.Allow("k"); !result.Allowed {
+			t.Fatalf("request %d: Allow() = %+v, want allowed", i, result)
+		}
+	}
+
+	result, _ := window.Allow("k")
+	if result.Allowed || result.RetryAfter != 12500*time.Millisecond {
+		t.Errorf("Allow() = %+v, want limited until a quarter of the window slid out", result)
+	}
+
+	// 12.5s later, the previous window weighs 0.75 * 4 = 3 requests
+	clock.now = clock.now.Add(12500 * time.Millisecond)
+	if result, _ := window.Allow("k"); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("Allow() = %+v, want allowed with nothing remaining", result)
+	}
+	if result, _ := window.Allow("k"); result.Allowed {
+		t.Errorf("Allow() = %+v, want limited", result)
+	}
+
+	clock.now = clock.now.Add(20 * time.Second)
+	if result, _ := window.Allow("k"); !result.Allowed || result.Remaining != 3 {
+		t.Errorf("Allow() = %+v, want the counts expired", result)
+	}
+}
@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bytom/bytom/errors"
+	"github.com/gin-gonic/gin"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+// the headers of the rate limit
+const (
+	HeaderLimit      = "X-RateLimit-Limit"
+	HeaderRemaining  = "X-RateLimit-Remaining"
+	HeaderReset      = "X-RateLimit-Reset"
+	HeaderRetryAfter = "Retry-After"
+)
+
+// LimitError is returned by the rate limit front filter when the request is limited
+type LimitError struct {
+	Key        string
+	RetryAfter time.Duration
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %v", e.RetryAfter)
+}
+
+// RegisterError register the LimitError to be responded with the code and HTTP 429
+func RegisterError(r *handler.ErrorRegistry, code int) *handler.ErrorRegistry {
+	return handler.RegisterType[*LimitError](r, handler.ErrorSpec{HTTPStatus: http.StatusTooManyRequests, Code: code})
+}
+
+// KeyFunc extract the key which the requests are limited by, the request is not limited if the key is empty
+type KeyFunc func(c *gin.Context) (string, error)
+
+// ByIP limit the requests by the client IP, which is the remote address of the connection. The X-Forwarded-For
+// header is honoured only if the remote address is one of the trusted proxies parsed by handler.ParseTrustedProxies,
+// and then the client IP is the rightmost forwarded address which is not a trusted proxy.
+func ByIP(trustedProxies ...*net.IPNet) KeyFunc {
+	return func(c *gin.Context) (string, error) {
+		return "ip:" + clientIP(c.Request, trustedProxies), nil
+	}
+}
+
+// clientIP return the remote IP of the request, or the forwarded one if the remote IP is a trusted proxy
+func clientIP(r *http.Request, trusted []*net.IPNet) string {
+	ip, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		ip = strings.TrimSpace(r.RemoteAddr)
+	}
+	if !handler.IsTrustedProxy(ip, trusted) {
+		return ip
+	}
+
+	// the proxies append the address they received from, so walk the header from the nearest hop
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		ip = hop
+		if !handler.IsTrustedProxy(hop, trusted) {
+			break
+		}
+	}
+	return ip
+}
+
+// ByHeader limit the requests by the value of the header, such as the api key header
+func ByHeader(header string) KeyFunc {
+	return func(c *gin.Context) (string, error) {
+		value := c.GetHeader(header)
+		if value == "" {
+			return "", nil
+		}
+		return "header:" + value, nil
+	}
+}
+
+// ByContext limit the requests by the string value set into the gin context by the previous filters,
+// such as the authenticated client
+func ByContext(key string) KeyFunc {
+	return func(c *gin.Context) (string, error) {
+		value := c.GetString(key)
+		if value == "" {
+			return "", nil
+		}
+		return "ctx:" + value, nil
+	}
+}
+
+// Options is the options of the rate limit front filter
+type Options struct {
+	// Limiter is required
+	Limiter Limiter
+	// Key extract the key of the request, ByIP is used if it is nil
+	Key KeyFunc
+	// Prefix is prepended to the keys, so the limits of the different routes are counted separately
+	Prefix string
+}
+
+// New return a front filter which limit the requests, the X-RateLimit-* headers are set for the limited keys,
+// and the Retry-After header is set with LimitError if the request is not allowed. It panics if the Limiter is nil.
+func New(opts Options) handler.FrontFilter {
+	if opts.Limiter == nil {
+		panic("the limiter of the rate limit is nil")
+	}
+	if opts.Key == nil {
+		opts.Key = ByIP()
+	}
+
+	return func(c *gin.Context) error {
+		key, err := opts.Key(c)
+		if err != nil {
+			return errors.Wrap(err, "extract rate limit key")
+		}
+		if key == "" {
+			return nil
+		}
+
+		result, err := opts.Limiter.Allow(opts.Prefix + key)
+		if err != nil {
+			return errors.Wrap(err, "rate limit")
+		}
+
+		c.Header(HeaderLimit, strconv.Itoa(result.Limit))
+		c.Header(HeaderRemaining, strconv.Itoa(result.Remaining))
+		c.Header(HeaderReset, strconv.FormatInt(ceilSeconds(result.Reset), 10))
+		if result.Allowed {
+			return nil
+		}
+
+		retryAfter := ceilSeconds(result.RetryAfter)
+		if retryAfter < 1 {
+			retryAfter = 1
+		}
+		c.Header(HeaderRetryAfter, strconv.FormatInt(retryAfter, 10))
+		return &LimitError{Key: key, RetryAfter: result.RetryAfter}
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
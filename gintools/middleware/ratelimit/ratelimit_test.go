@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+func TestRateLimitFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	filter := New(Options{Limiter: NewTokenBucket(NewMemoryStore(), 1, time.Minute), Key: ByHeader("X-API-Key"), Prefix: "ping:"})
+	h := handler.NewHandler(nil, []handler.FrontFilter{filter}, nil).SetResponseAdaptor(&handler.StatusResponse{})
+	RegisterError(h.ErrorRegistry(), 42900)
+
+	engine := gin.New()
+	engine.GET("/ping", h.HandleMiddleware(func(c *gin.Context) (string, error) {
+		return "pong", nil
+	}))
+
+	request := func(apiKey string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		if apiKey != "" {
+			r.Header.Set("X-API-Key", apiKey)
+		}
+		engine.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := request("k1"); w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "1" || w.Header().Get(HeaderRemaining) != "0" {
+		t.Fatalf("first request = %d %v, want 200 with the rate limit headers", w.Code, w.Header())
+	}
+
+	w := request("k1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get(HeaderRetryAfter) != "60" {
+		t.Errorf("second request = %d %v, want 429 and retry after 60s", w.Code, w.Header())
+	}
+	if body := w.Body.String(); !strings.Contains(body, `"code":42900`) {
+		t.Errorf("body = %s, want the configured code", body)
+	}
+
+	if w := request("k2"); w.Code != http.StatusOK {
+		t.Errorf("other key = %d, want 200", w.Code)
+	}
+	if w := request(""); w.Code != http.StatusOK || w.Header().Get(HeaderLimit) != "" {
+		t.Errorf("no key = %d %v, want not limited", w.Code, w.Header())
+	}
+}
+
+func TestByIP(t *testing.T) {
+	proxies, err := handler.ParseTrustedProxies("10.0.0.0/8", "192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := ByIP(proxies...)
+
+	cases := []struct {
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{remoteAddr: "1.2.3.4:5678", want: "ip:1.2.3.4"},
+		{remoteAddr: "1.2.3.4:5678", forwarded: "9.9.9.9", want: "ip:1.2.3.4"},
+		{remoteAddr: "10.0.0.2:80", forwarded: "9.9.9.9, 5.6.7.8", want: "ip:5.6.7.8"},
+		{remoteAddr: "10.0.0.2:80", forwarded: "9.9.9.9, 5.6.7.8, 192.168.1.1", want: "ip:5.6.7.8"},
+		{remoteAddr: "10.0.0.2:80", forwarded: "10.0.0.3", want: "ip:10.0.0.3"},
+		{remoteAddr: "10.0.0.2:80", want: "ip:10.0.0.2"},
+	}
+	for i, cs := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.RemoteAddr = cs.remoteAddr
+		if cs.forwarded != "" {
+			c.Request.Header.Set("X-Forwarded-For", cs.forwarded)
+		}
+		if got, _ := key(c); got != cs.want {
+			t.Errorf("case %d: key = %q, want %q", i, got, cs.want)
+		}
+	}
+}
+
+func TestNewNilLimiter(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New() without limiter does not panic")
+		}
+	}()
+	New(Options{})
+}
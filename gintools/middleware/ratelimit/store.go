@@ -0,0 +1,131 @@
+package ratelimit
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// sweepInterval is the number of writes between the sweeps of the expired entries in MemoryStore
+const sweepInterval = 1024
+
+// Store hold the states of the limiters, the states are updated optimistically by CompareAndSwap,
+// so the store can be shared by multiple instances
+type Store interface {
+	// Get return the value of the key, and false if it is absent or expired
+	Get(key string) ([]byte, bool, error)
+	// CompareAndSwap set the value of the key which expires after ttl if its current value is old,
+	// a nil old means the key is absent. It returns false if the value has been changed.
+	CompareAndSwap(key string, old, new []byte, ttl time.Duration) (bool, error)
+}
+
+type memoryEntry struct {
+	value  []byte
+	expiry time.Time
+}
+
+// MemoryStore is an in-memory Store for single instance services
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	writes  int
+	now     func() time.Time
+}
+
+// NewMemoryStore return an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]memoryEntry), now: time.Now}
+}
+
+// Get return the value of the key
+func (s *MemoryStore) Get(key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.get(key)
+	return value, ok, nil
+}
+
+// CompareAndSwap set the value of the key if its current value is old
+func (s *MemoryStore) CompareAndSwap(key string, old, new []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.get(key)
+	if ok != (old != nil) || !bytes.Equal(current, old) {
+		return false, nil
+	}
+
+	s.entries[key] = memoryEntry{value: append([]byte(nil), new...), expiry: s.now().Add(ttl)}
+	if s.writes++; s.writes%sweepInterval == 0 {
+		s.sweep()
+	}
+	return true, nil
+}
+
+func (s *MemoryStore) get(key string) ([]byte, bool) {
+	entry, ok := s.entries[key]
+	if !ok || !s.now().Before(entry.expiry) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (s *MemoryStore) sweep() {
+	now := s.now()
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiry) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// casScript set KEYS[1] to ARGV[2] with the ttl ARGV[3] in milliseconds if its value is ARGV[1],
+// an empty ARGV[1] means the key is absent
+const casScript = `
+local current = redis.call('GET', KEYS[1])
+if (ARGV[1] == '' and not current) or current == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	return 1
+end
+return 0`
+
+// RedisClient is the minimal Redis client used by RedisStore, which is easily adapted from any client library
+type RedisClient interface {
+	// Get return the value of the key, and false if it is absent
+	Get(key string) (string, bool, error)
+	// Eval run the Lua script with the keys and args, and return its result
+	Eval(script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// RedisStore is a Store backed by Redis, whose compare-and-swap is a Lua script
+type RedisStore struct {
+	Client RedisClient
+	// Prefix is prepended to the keys in Redis
+	Prefix string
+}
+
+// Get return the value of the key
+func (s *RedisStore) Get(key string) ([]byte, bool, error) {
+	value, ok, err := s.Client.Get(s.Prefix + key)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return []byte(value), true, nil
+}
+
+// CompareAndSwap set the value of the key if its current value is old
+func (s *RedisStore) CompareAndSwap(key string, old, new []byte, ttl time.Duration) (bool, error) {
+	ttlMillis := ttl.Milliseconds()
+	if ttlMillis <= 0 {
+		ttlMillis = 1
+	}
+
+	result, err := s.Client.Eval(casScript, []string{s.Prefix + key}, string(old), string(new), ttlMillis)
+	if err != nil {
+		return false, err
+	}
+
+	swapped, _ := result.(int64)
+	return swapped == 1, nil
+}
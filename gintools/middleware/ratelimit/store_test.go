@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a local Redis fake which understands the compare-and-swap script only
+type fakeRedis struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: make(map[string]string)}
+}
+
+func (r *fakeRedis) Get(key string) (string, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	value, ok := r.values[key]
+	return value, ok, nil
+}
+
+func (r *fakeRedis) Eval(script string, keys []string, args ...interface{}) (interface{}, error) {
+	if script != casScript {
+		return nil, fmt.Errorf("unexpected script")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	current, ok := r.values[keys[0]]
+	if (args[0] == "" && !ok) || (ok && current == args[0]) {
+		r.values[keys[0]] = args[1].(string)
+		return int64(1), nil
+	}
+	return int64(0), nil
+}
+
+func TestStoreCompareAndSwap(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	memory := NewMemoryStore()
+	memory.now = func() time.Time { return now }
+
+	for name, store := range map[string]Store{"memory": memory, "redis": &RedisStore{Client: newFakeRedis(), Prefix: "rl:"}} {
+		if swapped, err := store.CompareAndSwap("k", nil, []byte("1"), time.Minute); err != nil || !swapped {
+			t.Fatalf("%s: CompareAndSwap(absent) = %v, %v, want swapped", name, swapped, err)
+		}
+		if swapped, _ := store.CompareAndSwap("k", nil, []byte("2"), time.Minute); swapped {
+			t.Errorf("%s: CompareAndSwap(absent) swapped the existing key", name)
+		}
+		if swapped, _ := store.CompareAndSwap("k", []byte("0"), []byte("2"), time.Minute); swapped {
+			t.Errorf("%s: CompareAndSwap() swapped with the stale value", name)
+		}
+		if swapped, _ := store.CompareAndSwap("k", []byte("1"), []byte("2"), time.Minute); !swapped {
+			t.Errorf("%s: CompareAndSwap() = false, want swapped with the current value", name)
+		}
+		if value, ok, _ := store.Get("k"); !ok || string(value) != "2" {
+			t.Errorf("%s: Get() = %q, %v, want 2", name, value, ok)
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok, _ := memory.Get("k"); ok {
+		t.Error("memory: Get() found the expired key")
+	}
+}
@@ -0,0 +1,334 @@
+// Package openapi generate the OpenAPI 3 document of the routes recorded in the route registry of handler
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the version of the OpenAPI specification the document follows
+const Version = "3.0.3"
+
+// Document is the OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+// Info is the metadata of the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Server is the url of the API server
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem is the operations of a path, keyed by the lower case method
+type PathItem map[string]*Operation
+
+// Operation describe a route. The extensions x-pagination, x-display, x-stream and x-error-codes describe the
+// pagination, the display support, the streaming and the error codes of the handler, which are used by the
+// client generators. The success response of a streaming operation is the schema of the items.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	Pagination  *Pagination          `json:"x-pagination,omitempty"`
+	Display     bool                 `json:"x-display,omitempty"`
+	Stream      bool                 `json:"x-stream,omitempty"`
+	ErrorCodes  []ErrorCode          `json:"x-error-codes,omitempty"`
+}
+
+// Parameter is a param of the path, query or header
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the json body of the request
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is a response of the operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components is the reusable schemas referred by the document
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Pagination describe the kind and the query params of the paginated operation
+type Pagination struct {
+	Kind       string `json:"kind"`
+	StartParam string `json:"startParam"`
+	LimitParam string `json:"limitParam"`
+}
+
+// ErrorCode describe an error code the operation may respond, HTTPStatus is omitted if the error has no specific status
+type ErrorCode struct {
+	Code       int    `json:"code"`
+	HTTPStatus int    `json:"status,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+const jsonContentType = "application/json"
+
+// Generate return the OpenAPI document of the routes, whose responses are in the envelope of handler.Response.
+// Only the envelope of StandardResponse, StatusResponse and NegotiatedResponse is described, so the document does
+// not match the responses of the handlers which use ProblemResponse or a custom ResponseAdaptor.
+func Generate(info Info, routes []handler.RouteInfo) *Document {
+	g := NewGenerator()
+	doc := &Document{OpenAPI: Version, Info: info, Paths: map[string]*PathItem{}}
+	for _, route := range routes {
+		path := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = &PathItem{}
+		}
+		(*doc.Paths[path])[strings.ToLower(route.Method)] = g.operation(route)
+	}
+
+	g.envelopeSchemas()
+	doc.Components.Schemas = g.Schemas()
+	return doc
+}
+
+// Serve return a gin processing function which respond the document of the routes registered in the registry,
+// the document is generated on every request so that it contains the routes registered after Serve is called
+func Serve(info Info, routes *handler.RouteRegistry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, Generate(info, routes.Routes()))
+	}
+}
+
+func (g *Generator) operation(route handler.RouteInfo) *Operation {
+	op := &Operation{
+		OperationID: route.Name,
+		Summary:     route.Summary,
+		Tags:        route.Tags,
+		Display:     route.Display,
+		Stream:      route.Stream,
+		Responses: map[string]*Response{
+			strconv.Itoa(http.StatusOK): {
+				Description: "success",
+				Content:     map[string]MediaType{jsonContentType: {Schema: g.successSchema(route)}},
+			},
+			"default": {
+				Description: "error",
+				Content:     map[string]MediaType{jsonContentType: {Schema: &Schema{Ref: "#/components/schemas/ErrorResponse"}}},
+			},
+		},
+	}
+
+	if route.Stream {
+		item := &Schema{}
+		if route.Response != nil {
+			item = g.Schema(route.Response)
+		}
+		op.Responses[strconv.Itoa(http.StatusOK)] = &Response{
+			Description: "the stream of the items",
+			Content:     map[string]MediaType{handler.MIMEEventStream: {Schema: item}, handler.MIMENDJSON: {Schema: item}},
+		}
+	}
+
+	op.Parameters = g.parameters(route)
+	if route.Request != nil && route.Method != http.MethodGet && route.Method != http.MethodHead {
+		if body := g.BodySchema(route.Request); body != nil {
+			op.RequestBody = &RequestBody{Required: len(body.Required) > 0, Content: map[string]MediaType{jsonContentType: {Schema: body}}}
+		}
+	}
+
+	switch route.Pagination {
+	case handler.PaginationOffset:
+		start, limit := route.PaginationOptions.Style.Params()
+		op.Pagination = &Pagination{Kind: route.Pagination, StartParam: start, LimitParam: limit}
+	case handler.PaginationCursor:
+		op.Pagination = &Pagination{Kind: route.Pagination, StartParam: "cursor", LimitParam: "limit"}
+	}
+	if op.Pagination != nil {
+		op.Parameters = append(op.Parameters,
+			&Parameter{Name: op.Pagination.StartParam, In: "query", Schema: g.paginationParam(route, false)},
+			&Parameter{Name: op.Pagination.LimitParam, In: "query", Schema: g.paginationParam(route, true)},
+		)
+	}
+
+	for _, spec := range route.Errors {
+		op.ErrorCodes = append(op.ErrorCodes, ErrorCode{Code: spec.Code, HTTPStatus: spec.HTTPStatus, Message: spec.Message})
+	}
+	return op
+}
+
+// parameters return the path params of the route, and the params bound from the uri, query, header and form tags
+func (g *Generator) parameters(route handler.RouteInfo) []*Parameter {
+	params := map[string]*Parameter{}
+	var order []string
+	add := func(param *Parameter) {
+		key := param.In + ":" + param.Name
+		if _, ok := params[key]; !ok {
+			order = append(order, key)
+		}
+		params[key] = param
+	}
+
+	for _, segment := range strings.Split(route.Path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			add(&Parameter{Name: segment[1:], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	if route.Request != nil {
+		g.fieldParameters(route.Request, add)
+	}
+
+	result := make([]*Parameter, 0, len(order))
+	for _, key := range order {
+		result = append(result, params[key])
+	}
+	return result
+}
+
+func (g *Generator) fieldParameters(t reflect.Type, add func(*Parameter)) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && !handler.HasSourceTag(field) && fieldType.Kind() == reflect.Struct {
+			g.fieldParameters(fieldType, add)
+			continue
+		}
+		if field.PkgPath != "" || fieldType == fileHeaderType || (fieldType.Kind() == reflect.Slice && fieldType.Elem() == reflect.PtrTo(fileHeaderType)) {
+			continue
+		}
+
+		for _, source := range handler.FieldSources() {
+			tag, ok := field.Tag.Lookup(source)
+			if !ok || tag == "-" {
+				continue
+			}
+
+			name, defaultValue := handler.ParseSourceTag(tag)
+			if name == "" {
+				name = field.Name
+			}
+
+			schema := g.Schema(field.Type)
+			required := applyBinding(schema, field)
+			if defaultValue != "" {
+				schema.Default = defaultValue
+			}
+
+			param := &Parameter{Name: name, In: parameterLocation(source), Schema: schema}
+			param.Required = required || param.In == "path"
+			add(param)
+		}
+	}
+}
+
+// paginationParam return the schema of the start or limit param by the pagination options of the route
+func (g *Generator) paginationParam(route handler.RouteInfo, limit bool) *Schema {
+	opts := route.PaginationOptions
+	switch {
+	case limit:
+		schema := &Schema{Type: "integer", Minimum: float64Ptr(0)}
+		if opts.DefaultLimit > 0 {
+			schema.Default = opts.DefaultLimit
+		}
+		if opts.MaxLimit > 0 {
+			schema.Maximum = float64Ptr(float64(opts.MaxLimit))
+		}
+		return schema
+	case route.Pagination == handler.PaginationCursor:
+		return &Schema{Type: "string"}
+	case opts.Style == handler.PageStyle:
+		return &Schema{Type: "integer", Minimum: float64Ptr(1), Default: 1}
+	default:
+		return &Schema{Type: "integer", Minimum: float64Ptr(0), Default: 0}
+	}
+}
+
+// successSchema return the schema of the standard envelope with the data of the route
+func (g *Generator) successSchema(route handler.RouteInfo) *Schema {
+	schema := &Schema{
+		Type:     "object",
+		Required: []string{"code", "msg"},
+		Properties: map[string]*Schema{
+			"code": {Type: "integer"},
+			"msg":  {Type: "string"},
+		},
+	}
+	if route.Response != nil {
+		schema.Properties["data"] = g.Schema(route.Response)
+	}
+	if route.Pagination != "" {
+		schema.Properties["pagination"] = g.Schema(reflect.TypeOf(handler.PaginationResp{}))
+	}
+	return schema
+}
+
+// envelopeSchemas register the schema of the standard error envelope
+func (g *Generator) envelopeSchemas() {
+	g.schemas["ErrorResponse"] = &Schema{
+		Type:     "object",
+		Required: []string{"code", "msg"},
+		Properties: map[string]*Schema{
+			"code":   {Type: "integer"},
+			"msg":    {Type: "string"},
+			"errors": {Type: "array", Items: g.Schema(reflect.TypeOf(handler.FieldError{}))},
+		},
+	}
+}
+
+// openAPIPath convert the params of the gin path to the OpenAPI form, such as /users/:id to /users/{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func parameterLocation(source string) string {
+	switch source {
+	case "uri":
+		return "path"
+	case "header":
+		return "header"
+	default:
+		return "query"
+	}
+}
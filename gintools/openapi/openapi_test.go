@@ -0,0 +1,122 @@
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+type order struct {
+	ID     uint64 `json:"id"`
+	Amount uint64 `json:"amount"`
+}
+
+type getOrderReq struct {
+	ID    uint64 `uri:"id" json:"-"`
+	Token string `header:"X-Token" json:"-" binding:"required"`
+}
+
+type createOrderReq struct {
+	Amount uint64 `json:"amount" binding:"required,gt=0"`
+	Memo   string `json:"memo"`
+	DryRun bool   `json:"-" query:"dry_run,default=false"`
+}
+
+type listOrdersReq struct {
+	handler.Display
+	Status string `json:"-" form:"status"`
+}
+
+func newTestRoutes() (*gin.Engine, *handler.Handler) {
+	errNotFound := errors.New("order not found")
+	h := handler.NewHandler(map[error]int{errNotFound: 40400}, nil, nil).SetValidationErrCode(40000)
+
+	engine := gin.New()
+	orders := h.Group(engine, "/v1/orders", handler.WithRouteOptions(handler.WithTags("orders")))
+	orders.GET("/:id", func(c *gin.Context, req *getOrderReq) (*order, error) {
+		return &order{ID: req.ID}, nil
+	}, handler.WithSummary("get an order"))
+	handler.HandleJSON(orders, http.MethodPost, "", func(c *gin.Context, req *createOrderReq) (*order, error) {
+		return &order{Amount: req.Amount}, nil
+	})
+	handler.HandlePaginated(orders, http.MethodPost, "/search", func(c *gin.Context, req *listOrdersReq, query *handler.PaginationQuery) ([]order, uint64, error) {
+		return nil, 0, nil
+	}, handler.WithName("searchOrders"), handler.WithPageLimit(20, 100))
+	return engine, h
+}
+
+func TestGenerate(t *testing.T) {
+	_, h := newTestRoutes()
+	doc := Generate(Info{Title: "orders", Version: "1.0"}, h.Routes().Routes())
+
+	get := (*doc.Paths["/v1/orders/{id}"])["get"]
+	if get == nil || get.OperationID != "getV1OrdersById" || get.Summary != "get an order" || !reflect.DeepEqual(get.Tags, []string{"orders"}) {
+		t.Fatalf("get operation = %+v", get)
+	}
+	if len(get.Parameters) != 2 || get.Parameters[0].In != "path" || get.Parameters[0].Schema.Type != "integer" || get.Parameters[1].Name != "X-Token" || !get.Parameters[1].Required {
+		t.Errorf("get parameters = %+v", get.Parameters)
+	}
+	if get.RequestBody != nil {
+		t.Errorf("get request body = %+v, want nil", get.RequestBody)
+	}
+	wantErrors := []ErrorCode{{Code: 40000, HTTPStatus: http.StatusBadRequest, Message: "validation error"}, {Code: 40400, Message: "order not found"}}
+	if !reflect.DeepEqual(get.ErrorCodes, wantErrors) {
+		t.Errorf("error codes = %+v, want %+v", get.ErrorCodes, wantErrors)
+	}
+	if data := get.Responses["200"].Content[jsonContentType].Schema.Properties["data"]; data.Ref != "#/components/schemas/order" {
+		t.Errorf("response data = %+v, want the ref of order", data)
+	}
+
+	create := (*doc.Paths["/v1/orders"])["post"]
+	body := create.RequestBody.Content[jsonContentType].Schema
+	if !create.RequestBody.Required || len(body.Properties) != 2 || body.Properties["amount"].ExclusiveMinimum != true {
+		t.Errorf("create request body = %+v", body)
+	}
+	if len(create.Parameters) != 1 || create.Parameters[0].Name != "dry_run" || create.Parameters[0].Schema.Default != "false" {
+		t.Errorf("create parameters = %+v", create.Parameters)
+	}
+
+	search := (*doc.Paths["/v1/orders/search"])["post"]
+	if search.Pagination == nil || *search.Pagination != (Pagination{Kind: handler.PaginationOffset, StartParam: "start", LimitParam: "limit"}) || !search.Display {
+		t.Errorf("search pagination = %+v, display = %v", search.Pagination, search.Display)
+	}
+	if limit := search.Parameters[len(search.Parameters)-1]; limit.Schema.Default != uint64(20) || *limit.Schema.Maximum != 100 || *limit.Schema.Minimum != 0 {
+		t.Errorf("limit param = %+v", limit.Schema)
+	}
+	if body := search.RequestBody.Content[jsonContentType].Schema; body.Properties["filter"] == nil || body.Properties["sort"] == nil {
+		t.Errorf("search request body = %+v, want the display fields", body)
+	}
+	success := search.Responses["200"].Content[jsonContentType].Schema
+	if success.Properties["data"].Type != "array" || success.Properties["pagination"] == nil {
+		t.Errorf("search response = %+v, want the items and the pagination", success)
+	}
+	if doc.Components.Schemas["ErrorResponse"] == nil || doc.Components.Schemas["FieldError"] == nil {
+		t.Error("components lack the error envelope")
+	}
+}
+
+func TestServe(t *testing.T) {
+	engine, h := newTestRoutes()
+	engine.GET("/openapi.json", Serve(Info{Title: "orders", Version: "1.0"}, h.Routes()))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	var doc Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode document: %v", err)
+	}
+	if doc.OpenAPI != Version || len(doc.Paths) != 3 {
+		t.Errorf("document = %s %d paths, want %s with 3 paths", doc.OpenAPI, len(doc.Paths), Version)
+	}
+}
@@ -0,0 +1,305 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"math/big"
+	"mime/multipart"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bytom/community/gintools/middleware/handler"
+)
+
+// Schema is the JSON Schema of OpenAPI 3.0
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *uint64            `json:"minLength,omitempty"`
+	MaxLength            *uint64            `json:"maxLength,omitempty"`
+	MinItems             *uint64            `json:"minItems,omitempty"`
+	MaxItems             *uint64            `json:"maxItems,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	durationType       = reflect.TypeOf(time.Duration(0))
+	rawMessageType     = reflect.TypeOf(json.RawMessage{})
+	bigIntType         = reflect.TypeOf(big.Int{})
+	bigRatType         = reflect.TypeOf(big.Rat{})
+	fileHeaderType     = reflect.TypeOf(multipart.FileHeader{})
+	jsonMarshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType  = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	emptyInterfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// Generator derive the schemas from the Go types, the named struct types are collected as the component schemas
+type Generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+// NewGenerator return a schema generator
+func NewGenerator() *Generator {
+	return &Generator{schemas: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// Schemas return the component schemas of the named struct types the generator has met
+func (g *Generator) Schemas() map[string]*Schema {
+	return g.schemas
+}
+
+// Schema return the schema of the type by the json encoding of it, a named struct type is referred by $ref
+func (g *Generator) Schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64", Description: "nanoseconds"}
+	case rawMessageType, emptyInterfaceType:
+		return &Schema{}
+	case bigIntType:
+		return &Schema{Type: "integer"}
+	case bigRatType:
+		return &Schema{Type: "string", Format: "decimal"}
+	case fileHeaderType:
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	if implements(t, jsonMarshalerType) {
+		return &Schema{}
+	}
+	if implements(t, textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Minimum: float64Ptr(0)}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "uint64", Minimum: float64Ptr(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.Schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.Schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, nil)
+		}
+		return &Schema{Ref: "#/components/schemas/" + g.component(t)}
+	}
+	return &Schema{}
+}
+
+// BodySchema return the inline schema of the fields of the request struct which are bound from the json body,
+// it returns nil if there are no such fields
+func (g *Generator) BodySchema(t reflect.Type) *Schema {
+	schema := g.structSchema(t, func(field reflect.StructField) bool {
+		_, hasJSON := field.Tag.Lookup("json")
+		return hasJSON || !handler.HasSourceTag(field)
+	})
+	if len(schema.Properties) == 0 {
+		return nil
+	}
+	return schema
+}
+
+// component register the struct type as a component schema, and return the unique name of it
+func (g *Generator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := componentName(t.Name())
+	for i := 2; g.schemas[name] != nil; i++ {
+		name = componentName(t.Name()) + strconv.Itoa(i)
+	}
+
+	// register before generating, so that the recursive types refer to themselves
+	g.names[t] = name
+	g.schemas[name] = &Schema{}
+	*g.schemas[name] = *g.structSchema(t, nil)
+	return name
+}
+
+// structSchema return the object schema of the exported fields, the fields of the embedded structs are promoted
+func (g *Generator) structSchema(t reflect.Type, include func(reflect.StructField) bool) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.addFields(schema, t, include)
+	return schema
+}
+
+func (g *Generator) addFields(schema *Schema, t reflect.Type, include func(reflect.StructField) bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts := parseTag(field.Tag.Get("json"))
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			g.addFields(schema, fieldType, include)
+			continue
+		}
+		if field.PkgPath != "" || (include != nil && !include(field)) {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := g.Schema(field.Type)
+		if strings.Contains(opts, "string") && fieldSchema.Type != "" && fieldSchema.Type != "object" && fieldSchema.Type != "array" {
+			fieldSchema = &Schema{Type: "string"}
+		}
+		if applyBinding(fieldSchema, field) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = fieldSchema
+	}
+}
+
+// applyBinding apply the validator rules of the binding tag to the schema, and return whether the field is required.
+// The rules of a referred schema are ignored, as they can not be mixed with $ref in OpenAPI 3.0.
+func applyBinding(schema *Schema, field reflect.StructField) bool {
+	required := false
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+
+		switch name {
+		case "dive":
+			return required
+		case "required":
+			required = true
+			continue
+		}
+		if schema.Ref != "" {
+			continue
+		}
+
+		switch name {
+		case "min", "gte":
+			setBound(schema, param, true, false)
+		case "max", "lte":
+			setBound(schema, param, false, false)
+		case "gt":
+			setBound(schema, param, true, true)
+		case "lt":
+			setBound(schema, param, false, true)
+		case "len":
+			setBound(schema, param, true, false)
+			setBound(schema, param, false, false)
+		case "email", "uuid", "ipv4", "ipv6", "ip":
+			schema.Format = name
+		case "url", "uri":
+			schema.Format = "uri"
+		}
+	}
+	return required
+}
+
+// setBound set the length bound of strings, the size bound of arrays or the value bound of numbers
+func setBound(schema *Schema, param string, lower, exclusive bool) {
+	switch schema.Type {
+	case "string", "array":
+		n, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return
+		}
+		if exclusive && lower {
+			n++
+		} else if exclusive && n > 0 {
+			n--
+		}
+
+		switch {
+		case schema.Type == "string" && lower:
+			schema.MinLength = &n
+		case schema.Type == "string":
+			schema.MaxLength = &n
+		case lower:
+			schema.MinItems = &n
+		default:
+			schema.MaxItems = &n
+		}
+
+	case "integer", "number":
+		f, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			schema.Minimum, schema.ExclusiveMinimum = &f, exclusive
+		} else {
+			schema.Maximum, schema.ExclusiveMaximum = &f, exclusive
+		}
+	}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PtrTo(t).Implements(iface)
+}
+
+func parseTag(tag string) (string, string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
+
+// componentName strip the characters which are not allowed in the component names, such as the brackets of generic types
+func componentName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
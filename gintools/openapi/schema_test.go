@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaAddress struct {
+	City string `json:"city" binding:"required"`
+}
+
+type schemaNode struct {
+	Name     string        `json:"name" binding:"required,min=1,max=32"`
+	Age      uint8         `json:"age,omitempty" binding:"lte=150"`
+	Score    float64       `json:"score" binding:"gt=0"`
+	Amount   uint64        `json:"amount,string"`
+	Email    string        `json:"email" binding:"omitempty,email"`
+	Tags     []string      `json:"tags" binding:"max=3,dive,min=1"`
+	Created  time.Time     `json:"created"`
+	Raw      []byte        `json:"raw"`
+	Meta     interface{}   `json:"meta"`
+	Address  schemaAddress `json:"address" binding:"required"`
+	Children []*schemaNode `json:"children"`
+	Secret   string        `json:"-"`
+	internal string
+	schemaEmbedded
+}
+
+type schemaEmbedded struct {
+	Version int `json:"version"`
+}
+
+func TestGeneratorSchema(t *testing.T) {
+	g := NewGenerator()
+	if ref := g.Schema(reflect.TypeOf(&schemaNode{})); ref.Ref != "#/components/schemas/schemaNode" {
+		t.Fatalf("Schema() = %+v, want the ref of the component", ref)
+	}
+
+	node := g.Schemas()["schemaNode"]
+	if !reflect.DeepEqual(node.Required, []string{"name", "address"}) {
+		t.Errorf("required = %v, want [name address]", node.Required)
+	}
+
+	var names []string
+	for name := range node.Properties {
+		names = append(names, name)
+	}
+	if len(names) != 12 || node.Properties["Secret"] != nil || node.Properties["internal"] != nil || node.Properties["version"] == nil {
+		t.Errorf("properties = %v, want the exported json fields with the embedded fields promoted", names)
+	}
+
+	cases := map[string]string{
+		"name":     `{"type":"string","minLength":1,"maxLength":32}`,
+		"age":      `{"type":"integer","format":"int32","minimum":0,"maximum":150}`,
+		"score":    `{"type":"number","format":"double","minimum":0,"exclusiveMinimum":true}`,
+		"amount":   `{"type":"string"}`,
+		"email":    `{"type":"string","format":"email"}`,
+		"tags":     `{"type":"array","items":{"type":"string"},"maxItems":3}`,
+		"created":  `{"type":"string","format":"date-time"}`,
+		"raw":      `{"type":"string","format":"byte"}`,
+		"meta":     `{}`,
+		"address":  `{"$ref":"#/components/schemas/schemaAddress"}`,
+		"children": `{"type":"array","items":{"$ref":"#/components/schemas/schemaNode"}}`,
+	}
+	for name, want := range cases {
+		b, err := json.Marshal(node.Properties[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != want {
+			t.Errorf("property %s = %s, want %s", name, b, want)
+		}
+	}
+
+	if address := g.Schemas()["schemaAddress"]; address == nil || !reflect.DeepEqual(address.Required, []string{"city"}) {
+		t.Errorf("schemaAddress = %+v, want the component of the nested struct", address)
+	}
+}
@@ -1,27 +1,35 @@
 module github.com/bytom/community
 
-go 1.14
+go 1.18
 
 require (
 	github.com/bytom/bytom v0.0.0-20200803085506-0cfa2427fd50
+	github.com/gin-gonic/gin v1.3.0
+	github.com/golang/protobuf v1.4.3
+	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
+	github.com/onrik/logrus v0.8.0
+	github.com/sirupsen/logrus v1.4.2
+	github.com/ugorji/go/codec v1.2.12
+	gopkg.in/go-playground/validator.v8 v8.18.2
+	gopkg.in/mgo.v2 v2.0.0-20190816093944-a6b53ec6cb22
+)
+
+require (
 	github.com/fastly/go-utils v0.0.0-20180712184237-d95a45783239 // indirect
 	github.com/gin-contrib/sse v0.1.0 // indirect
-	github.com/gin-gonic/gin v1.3.0
-	github.com/golang/protobuf v1.4.3 // indirect
 	github.com/jehiah/go-strftime v0.0.0-20171201141054-1d33003b3869 // indirect
 	github.com/jonboulle/clockwork v0.2.2 // indirect
 	github.com/json-iterator/go v1.1.10 // indirect
-	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
+	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
 	github.com/lestrrat-go/strftime v1.0.3 // indirect
 	github.com/mattn/go-isatty v0.0.12 // indirect
-	github.com/onrik/logrus v0.8.0
+	github.com/modern-go/concurrent v0.0.0-20180228061459-e0a39a4cb421 // indirect
+	github.com/modern-go/reflect2 v0.0.0-20180701023420-4b7aa43c6742 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/sirupsen/logrus v1.4.2
 	github.com/tebeka/strftime v0.1.5 // indirect
-	github.com/ugorji/go v1.2.1 // indirect
 	golang.org/x/net v0.0.0-20201209123823-ac852fbbde11 // indirect
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // indirect
+	google.golang.org/protobuf v1.23.0 // indirect
 	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
-	gopkg.in/go-playground/validator.v8 v8.18.2 // indirect
-	gopkg.in/mgo.v2 v2.0.0-20190816093944-a6b53ec6cb22
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )